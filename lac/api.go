@@ -10,7 +10,11 @@
 package lac
 
 import (
+	"fmt"
+	"math"
 	"reflect"
+	"sync/atomic"
+	"unicode/utf8"
 	"unsafe"
 )
 
@@ -25,13 +29,79 @@ var (
 	BugfixCorruptOtherMem   = true
 
 	ZeroMemOnFree = false
+
+	// debug mode records every struct allocation for the pointer check,
+	// which can consume huge memory on workloads allocating millions of
+	// objects per cycle. past DbgMaxScanObjs objects only 1 in
+	// DbgScanSampleRate allocations is recorded, so debug mode stays usable
+	// on realistic data volumes. 0 means unlimited.
+	// NOTE: sampled checking catches most but not all violations.
+	DbgMaxScanObjs    = 0
+	DbgScanSampleRate = 100
+
+	// an allocation larger than ChunkSize gets a dedicated chunk: never
+	// pooled, handed back to the GC (or the LargeAlloc owner) on reset.
+	// MaxChunkSize puts a ceiling on those — exceeding it is reported
+	// instead of silently reserving gigabytes; datasets that big but not
+	// needing contiguity belong in a StripedSlice. 0 means no ceiling.
+	MaxChunkSize = 0
+
+	// WaitBorrowsOnReset makes a reset spin until all borrows are returned
+	// instead of reporting them. see Allocator.Borrow.
+	WaitBorrowsOnReset = false
+
+	// DisablePooling makes every chunk freshly allocated and dropped to the
+	// GC on reset, never reused within the process. chunk reuse makes
+	// use-after-reset corruption non-deterministic; with pooling off (plus
+	// the GC) the stale access is far more likely to crash right away.
+	// a debugging aid: trades performance for determinism.
+	DisablePooling = false
+
+	// overlap checking records every region handed out in the cycle and
+	// asserts each new allocation is disjoint from all of them: the
+	// definitive test of the bump logic across chunk advances and the
+	// large-chunk fallback. O(allocs) per allocation, debug mode only.
+	DbgCheckOverlap = false
+
+	// guard-page mode gives every allocation its own mapping followed by an
+	// inaccessible page, so overruns fault immediately with a stack trace.
+	// extremely slow (one mapping per alloc), for targeted debugging only.
+	// ignored on platforms without mmap/mprotect.
+	DbgGuardPages = false
+
+	// canary mode stamps a marker at known offsets of every chunk freed by a
+	// reset and verifies them at the next one: a clobbered canary pinpoints a
+	// goroutine writing through a retained pointer after the reset, which the
+	// read-side diagnosis cannot surface. debug mode only; the stamped chunks
+	// stay alive (unpooled) for one extra cycle.
+	DbgResetCanaries = false
+
+	// adaptive chunk sizing: on each reset the allocator looks at how many
+	// chunks the cycle consumed and retunes its own chunk size — doubling it
+	// past adaptGrowAt chunks, halving it below adaptShrinkAt — so heavy
+	// workloads stop paying per-chunk pool traffic without manual ChunkSize
+	// tuning. see adaptChunkSize for the bounds and the hysteresis band.
+	AdaptiveChunkSize = false
+
+	// a single object above this many bytes gets its type and size logged in
+	// debug mode: such objects fragment the arena (a chunk only serves what
+	// fits in its tail) and are usually better off on the heap.
+	// ChunkSize/2 is a reasonable value. 0 disables the warning.
+	DbgWarnLargeObject = 0
 )
 
 func (p *AllocatorPool) Get() *Allocator {
 	if p == nil || DisableAllLac {
 		return nil
 	}
+	if overGlobalLimit() {
+		// fall back to the native heap until memory frees.
+		return nil
+	}
 	ac := p.Pool.Get()
+	if p.debugMode && ac != nil {
+		p.trackAcquire(ac)
+	}
 	return ac
 }
 
@@ -39,10 +109,22 @@ func (ac *Allocator) Release() {
 	if ac == nil {
 		return
 	}
+	if ac.acPool.debugMode {
+		ac.acPool.trackRelease(ac)
+	}
 	ac.reset()
 	ac.acPool.Put(ac)
 }
 
+// ReleaseAll releases every allocator in one teardown, e.g. the phase
+// allocators of a request at its end. nil (native) entries are fine, so a
+// mixed set needs no filtering at the call site.
+func ReleaseAll(acs ...*Allocator) {
+	for _, ac := range acs {
+		ac.Release()
+	}
+}
+
 // IncRef should be called before and outside the new goroutine, never be in the new goroutine,
 // otherwise the execution of new goroutine may be delayed after the caller quit,
 // which may cause a UseAfterFree error. e.g.
@@ -84,18 +166,379 @@ func (ac *Allocator) DecRef() {
 	}
 }
 
+// sharedAcPool backs Acquire, the explicit binding-free lifecycle.
+var sharedAcPool = NewAllocatorPool("shared", nil, 10000, 64*1024, 0, 1024)
+
+// Acquire returns a pooled allocator with no goroutine binding attached: a
+// pure pool get for callers who manage allocator ownership explicitly and
+// want neither a pool of their own nor any per-goroutine lookup machinery.
+// Release is the counterpart, resetting it back into the pool. the names
+// New and Free are taken by the allocation and recycling functions.
+func Acquire() *Allocator {
+	return sharedAcPool.Get()
+}
+
+// tinyAcPool backs NewTiny allocators. small chunks and no prewarm: tiny
+// allocators are expected to touch a few KB each, in huge numbers.
+var tinyAcPool = NewAllocatorPool("tiny", nil, 1024, 8*1024, 0, 1024)
+
+// NewTiny returns a lightweight allocator for millions of tiny short-lived
+// arenas: nothing is preallocated up front (the chunk list and external
+// queues grow lazily on first use) and the chunks are small. Release works
+// as usual; the full-featured pooled lifecycle remains the fit for servers.
+func NewTiny() *Allocator {
+	ac := &Allocator{
+		acPool:    tinyAcPool,
+		chunkPool: tinyAcPool.chunkPool,
+
+		externalPtr:    newWeakUniqQueue(32, eq[unsafe.Pointer]),
+		externalSlice:  newWeakUniqQueue(32, eq[unsafe.Pointer]),
+		externalString: newWeakUniqQueue(32, eq[unsafe.Pointer]),
+		externalMap:    newWeakUniqQueue(32, anyEq),
+		externalFunc:   newWeakUniqQueue(32, interfaceEqual),
+
+		dbgScanObjs: newWeakUniqQueue(math.MaxInt, anyEq),
+	}
+	ac.refCnt.Store(1)
+	tinyAcPool.Stats.TotalCreatedAc.Add(1)
+	return ac
+}
+
+// deterministicAcPool backs NewDeterministic allocators. its chunk pool is
+// never actually used for chunks (they are always fresh), it only supplies
+// the ChunkSize and the pool identity.
+var deterministicAcPool = NewAllocatorPool("deterministic", nil, 64, 64*1024, 0, 0)
+
+// NewDeterministic returns an allocator for reproducible tests and fuzzing:
+// chunks are always freshly made, never taken from or returned to a pool, and
+// their memory is stamped with the detFillPattern byte. un-zeroed allocations
+// therefore read back a fixed pattern instead of whatever some earlier cycle
+// left behind, so a test that depends on uninitialized memory fails the same
+// way on every run.
+func NewDeterministic() *Allocator {
+	ac := &Allocator{
+		acPool:        deterministicAcPool,
+		chunkPool:     deterministicAcPool.chunkPool,
+		deterministic: true,
+
+		externalPtr:    newWeakUniqQueue(32, eq[unsafe.Pointer]),
+		externalSlice:  newWeakUniqQueue(32, eq[unsafe.Pointer]),
+		externalString: newWeakUniqQueue(32, eq[unsafe.Pointer]),
+		externalMap:    newWeakUniqQueue(32, anyEq),
+		externalFunc:   newWeakUniqQueue(32, interfaceEqual),
+
+		dbgScanObjs: newWeakUniqQueue(math.MaxInt, anyEq),
+	}
+	ac.refCnt.Store(1)
+	deterministicAcPool.Stats.TotalCreatedAc.Add(1)
+	return ac
+}
+
+// the process-wide default allocator, see SetDefault.
+var defaultAc atomic.Pointer[Allocator]
+
+// SetDefault marks ac as the process-wide default returned by Default: the
+// simple setup for CLIs and batch jobs that want one shared arena without
+// threading an *Allocator through every call. nil unsets it, and Default
+// falls back to the native allocator.
+// NOTE: the default is one plain allocator, not a per-goroutine binding.
+// using it from several goroutines needs external synchronization, or
+// IncRef/DecRef like any other shared allocator.
+func SetDefault(ac *Allocator) {
+	defaultAc.Store(ac)
+}
+
+// Default returns the allocator set by SetDefault, or the native (nil)
+// allocator when none is set.
+func Default() *Allocator {
+	return defaultAc.Load()
+}
+
+// Native returns the passthrough allocator: a nil *Allocator, whose methods
+// all fall back to the native heap. use it to make the passthrough mode
+// explicit at the call site instead of passing a bare nil.
+func Native() *Allocator {
+	return nil
+}
+
+// IsLinear reports whether ac really allocates from the arena.
+// The native (nil) allocator returns false, so a service can detect code
+// paths accidentally running everything through the passthrough mode.
+func (ac *Allocator) IsLinear() bool {
+	return ac != nil
+}
+
+// SetMaxGrowStep caps the geometric slice growth of Append: once a grow
+// would add more than step bytes, the slice grows by exactly step instead,
+// trading a few more relocations for a bounded allocation spike.
+// 0 restores unlimited growth; cleared when the allocator goes back to the pool.
+func (ac *Allocator) SetMaxGrowStep(step int) {
+	if ac == nil {
+		return
+	}
+	ac.maxGrowStep = step
+}
+
+// SetGeometricChunks makes every new chunk double the size of the previous
+// one, up to cap bytes: a big arena ends up with a few large chunks instead
+// of hundreds of fixed-size ones, while small arenas still start at the
+// pooled ChunkSize. grown chunks (cap != ChunkSize) count as dedicated: on
+// reset they go to the GC or the pool's LargeAlloc, never the chunk pool.
+// cleared when the allocator goes back to the pool.
+func (ac *Allocator) SetGeometricChunks(on bool, cap int) {
+	if ac == nil {
+		return
+	}
+	if !on {
+		atomic.StoreInt64(&ac.geomCap, 0)
+		return
+	}
+	if cap < ac.chunkPool.ChunkSize {
+		cap = ac.chunkPool.ChunkSize
+	}
+	atomic.StoreInt64(&ac.geomNext, int64(ac.chunkPool.ChunkSize))
+	atomic.StoreInt64(&ac.geomCap, int64(cap))
+}
+
+// WithOverflow gives ac a fixed byte budget and links a secondary allocator
+// from the same pool that takes every allocation past it, so an unpredictable
+// workload gets tiered accounting: Stats reports both sides, and alerting can
+// key on the overflow tier being touched at all. the overflow allocator is
+// released together with ac.
+// NOTE: the budget counts alloc calls only; Append's in-place chunk growth
+// bypasses it.
+func (ac *Allocator) WithOverflow(budget int) *Allocator {
+	if ac == nil {
+		return nil
+	}
+	if ac.overflow == nil {
+		ac.overflow = ac.acPool.Get()
+	}
+	atomic.StoreInt64(&ac.overflowBudget, int64(budget))
+	return ac
+}
+
+// BorrowToken is the receipt of a Borrow call, returned via Return.
+type BorrowToken struct {
+	ac *Allocator
+}
+
+// Borrow declares that the caller keeps reading lac objects for a while,
+// e.g. from another goroutine. unlike IncRef it does not delay the recycle:
+// a reset with outstanding borrows panics in debug mode (or waits, see
+// WaitBorrowsOnReset), turning the racy reset into a deterministic report.
+func (ac *Allocator) Borrow() *BorrowToken {
+	if ac == nil {
+		return &BorrowToken{}
+	}
+	ac.borrows.Add(1)
+	return &BorrowToken{ac: ac}
+}
+
+// Return gives the borrow back. extra calls on the same token are no-ops.
+func (t *BorrowToken) Return() {
+	if t.ac != nil {
+		t.ac.borrows.Add(-1)
+		t.ac = nil
+	}
+}
+
+// Rotate swaps *acp for a fresh allocator from the same pool and returns
+// the old, filled one: the producer half of a double-buffered pipeline keeps
+// allocating through acp without ever blocking on the consumer, who reads
+// the returned allocator and Releases it when done.
+// only the producer's variable is swapped; publishing the returned allocator
+// to the consumer goroutine is the caller's handoff (e.g. via a channel).
+func Rotate(acp **Allocator) *Allocator {
+	old := *acp
+	if old == nil {
+		return nil
+	}
+	*acp = old.acPool.Get()
+	return old
+}
+
+// Weak is a finalizer-free weak handle to an arena object: it remembers the
+// allocator's reset generation at creation, so once the owning cycle ends
+// the handle reports dead instead of handing out recycled memory. caches can
+// hold these across cycles without risking use-after-free.
+// NOTE: validity is per cycle, not per object; the handle cannot outlive a
+// reset even if the same address is handed out again.
+type Weak[T any] struct {
+	ac  *Allocator
+	gen int64
+	p   *T
+}
+
+// MakeWeak wraps the arena object p into a weak handle.
+// objects of the native (nil) allocator are GC-managed, their handles stay
+// valid as long as the handle itself keeps them reachable.
+func MakeWeak[T any](ac *Allocator, p *T) Weak[T] {
+	if ac == nil {
+		return Weak[T]{p: p}
+	}
+	return Weak[T]{ac: ac, gen: ac.generation.Load(), p: p}
+}
+
+// Get returns the object, or ok=false once the owning allocator was reset.
+func (w Weak[T]) Get() (*T, bool) {
+	if w.p == nil {
+		return nil, false
+	}
+	if w.ac != nil && w.ac.generation.Load() != w.gen {
+		return nil, false
+	}
+	return w.p, true
+}
+
+// Alive reports validity without handing out the pointer, for cache sweeps
+// that only want to evict dead handles.
+func (w Weak[T]) Alive() bool {
+	_, ok := w.Get()
+	return ok
+}
+
+// Branch forks a child allocator for speculative work: the child reads the
+// parent's objects in place (the chunks are shared, nothing is copied) and
+// writes all new allocations into its own fresh chunks, so releasing the
+// child frees only those and leaves the parent intact.
+// the parent is frozen by a borrow while the branch is live: resetting it
+// is reported (or waits, see WaitBorrowsOnReset), and the caller must not
+// allocate from it either, or branch objects may point into recycled memory.
+func (ac *Allocator) Branch() *Allocator {
+	if ac == nil {
+		return nil
+	}
+	child := ac.acPool.Get()
+	if child == nil {
+		return nil
+	}
+	child.parentToken = ac.Borrow()
+	return child
+}
+
+// Child returns a sub-allocator for a nested scope, e.g. the scratch arena
+// of one subtree: it pulls chunks from the same pool but tracks its own
+// chunk list, so resetting or releasing the child frees only the child's
+// allocations and the parent lives on. cheaper than a separate pool, and
+// unlike Branch it does not freeze the parent — the child must therefore not
+// hold pointers into parent memory, and children should be released before
+// the parent is reset.
+func (ac *Allocator) Child() *Allocator {
+	if ac == nil {
+		return nil
+	}
+	return ac.acPool.Get()
+}
+
+// ScopeToken marks an allocation offset captured by Enter, rolled back to
+// by Leave.
+type ScopeToken struct {
+	ac       *Allocator
+	chunkCnt int
+	cur      unsafe.Pointer
+	used     int64
+	done     bool
+}
+
+// Enter opens a scratch scope: everything allocated until the matching
+// Leave is freed by it, and the same memory serves the next scope again —
+// the fit for recursion where each level wants scratch freed on return, so
+// peak usage is bounded by the depth instead of the total number of calls.
+// scopes nest LIFO; Leave is safe to call twice (the second is a no-op).
+// single-threaded only, like the in-place grow paths.
+func (ac *Allocator) Enter() ScopeToken {
+	if ac == nil {
+		return ScopeToken{}
+	}
+	t := ScopeToken{ac: ac, chunkCnt: len(ac.chunks), cur: ac.curChunk}
+	if ac.curChunk != nil {
+		t.used = (*sliceHeader)(ac.curChunk).Len
+	}
+	return t
+}
+
+// Leave rolls the allocator back to the offset captured at Enter, freeing
+// every allocation made inside the scope. the caller must drop all
+// references into the scope first.
+func (t *ScopeToken) Leave() {
+	ac := t.ac
+	if ac == nil || t.done {
+		return
+	}
+	t.done = true
+	if ac.refCnt.Load() != 1 {
+		errorf(ac.acPool, "potential bug: Leave on a shared allocator")
+		return
+	}
+
+	if len(ac.chunks) > t.chunkCnt {
+		ac.releaseChunks(ac.chunks[t.chunkCnt:])
+		for i := t.chunkCnt; i < len(ac.chunks); i++ {
+			ac.chunks[i] = nil
+		}
+		ac.chunks = ac.chunks[:t.chunkCnt]
+	}
+	ac.curChunk = t.cur
+	if t.cur != nil {
+		(*sliceHeader)(t.cur).Len = t.used
+	}
+
+	// drop the debug records of the rolled-back region, or the checker scans
+	// freed objects and the overlap check false-hits on reused space.
+	if ac.acPool.debugMode {
+		objs := ac.dbgScanObjs.slice
+		kept := objs[:0]
+		for _, o := range objs {
+			if ac.inUsedRange(uintptr(data(o))) {
+				kept = append(kept, o)
+			}
+		}
+		for i := len(kept); i < len(objs); i++ {
+			objs[i] = nil
+		}
+		ac.dbgScanObjs.slice = kept
+
+		if DbgCheckOverlap {
+			regs := ac.regions[:0]
+			for _, r := range ac.regions {
+				if ac.inUsedRange(r.start) {
+					regs = append(regs, r)
+				}
+			}
+			ac.regions = regs
+		}
+	}
+}
+
 //============================================================================
 // Allocation APIs
 //============================================================================
 
+// New allocates a zeroed T from the arena, like the builtin new.
+// zeroing is guaranteed (not just likely from fresh chunks): reused chunk
+// memory is cleared before the object is handed out, so pointer fields start
+// nil and are safe for the GC and the pointer checker right away. this makes
+// two-phase building of graphs with forward references safe: allocate all
+// nodes first (arena pointers are stable until reset), then backfill the
+// pointer fields in a second pass; a CheckExternalPointers run between the
+// phases sees only nil or wired fields, never garbage.
 func New[T any](ac *Allocator) (r *T) {
 	if ac == nil {
 		return new(T)
 	}
 
 	r = (*T)(ac.alloc(int(unsafe.Sizeof(*r)), true))
+	if ac.DbgAllocTrace != nil {
+		ac.DbgAllocTrace(int(unsafe.Sizeof(*r)), reflect.TypeOf(r).Elem())
+	}
 	if ac.acPool.debugMode {
-		if reflect.TypeOf(r).Elem().Kind() == reflect.Struct {
+		typ := reflect.TypeOf(r).Elem()
+		ac.maybeWarnLargeObject(int(unsafe.Sizeof(*r)), typ)
+		if containsSyncPrimitives(typ) {
+			errorf(ac.acPool, "potential bug: %v embeds a sync primitive, whose state dies with the cycle while stale references can still lock it; allocate it from the heap", typ)
+		}
+		if typ.Kind() == reflect.Struct {
 			ac.debugScan(r)
 		}
 	}
@@ -130,9 +573,18 @@ func NewFrom[T any](ac *Allocator, src *T) *T {
 	// safe to avoid zeroing the memory because no wb can be triggered here.
 	ret := (*T)(ac.alloc(int(sz), false))
 	memmoveNoHeapPointers(unsafe.Pointer(ret), unsafe.Pointer(src), sz)
+	if ac.DbgAllocTrace != nil {
+		ac.DbgAllocTrace(int(sz), reflect.TypeOf(ret).Elem())
+	}
 
 	if ac.acPool.debugMode {
-		if reflect.TypeOf(ret).Elem().Kind() == reflect.Struct {
+		typ := reflect.TypeOf(ret).Elem()
+		ac.maybeWarnLargeObject(int(sz), typ)
+		if containsSyncPrimitives(typ) {
+			// worse than New: the copy itself duplicates any used lock state.
+			errorf(ac.acPool, "potential bug: %v embeds a sync primitive, copying it into the arena duplicates its state; allocate it from the heap", typ)
+		}
+		if typ.Kind() == reflect.Struct {
 			ac.debugScan(ret)
 		}
 	}
@@ -141,6 +593,10 @@ func NewFrom[T any](ac *Allocator, src *T) *T {
 
 // NewSlice does not zero the slice automatically, this is OK with most cases and can improve the performance.
 // zero it yourself for your need.
+// NOTE: len is the initialized-prefix contract with the pointer checker: it
+// scans elements [0, len) only, so a pointer slice filled lazily must start
+// at len 0 and grow via Append (or SetLen) as elements become valid, never
+// claim a len covering unwritten memory.
 func NewSlice[T any](ac *Allocator, len, cap int) (r []T) {
 	if ac == nil {
 		return make([]T, len, cap)
@@ -164,9 +620,92 @@ func NewSlice[T any](ac *Allocator, len, cap int) (r []T) {
 	if !BugfixClearPointerInMem {
 		zero = false
 	}
-	slice.Data = ac.alloc(cap*int(unsafe.Sizeof(t)), zero)
+	// NOTE: use the element size, not the pointer size,
+	// otherwise elements larger than a pointer overrun the allocation.
+	elemSz := int(unsafe.Sizeof(*t))
+	slice.Data = ac.alloc(cap*elemSz, zero)
 	slice.Len = int64(len)
 	slice.Cap = int64(cap)
+	if ac.DbgAllocTrace != nil {
+		ac.DbgAllocTrace(cap*elemSz, reflect.TypeOf(r))
+	}
+	if ac.acPool.debugMode {
+		ac.maybeWarnLargeObject(cap*elemSz, reflect.TypeOf(r))
+	}
+	return r
+}
+
+// NewSliceAligned is NewSlice with the backing aligned to align bytes
+// (a power of two): alloc only guarantees pointer alignment, not enough for
+// element types carrying a SIMD-width field. with the base aligned, every
+// element stays aligned as long as the element size is a multiple of align,
+// which Go's struct padding gives any type sized to its alignment.
+func NewSliceAligned[T any](ac *Allocator, len, cap, align int) (r []T) {
+	if align <= 0 || align&(align-1) != 0 {
+		panic("NewSliceAligned: align must be a power of two")
+	}
+	if align <= ptrSize {
+		return NewSlice[T](ac, len, cap)
+	}
+
+	var t *T
+	elemSz := int(unsafe.Sizeof(*t))
+
+	if len > cap {
+		panic("NewSliceAligned: cap out of range")
+	}
+	if cap == 0 {
+		return nil
+	}
+
+	if ac == nil {
+		if ContainsPointers(reflect.TypeOf(t).Elem()) {
+			// a byte backing is noscan, pointers stored in it would be
+			// invisible to the GC. step whole elements instead: an aligned
+			// element exists iff the base is aligned to gcd(elemSz, align),
+			// e.g. never off an 8-aligned base once elemSz is a multiple
+			// of align.
+			g := align
+			for elemSz%g != 0 {
+				g >>= 1
+			}
+			buf := make([]T, cap+align/g)
+			addr := uintptr(unsafe.Pointer(&buf[0]))
+			if addr%uintptr(g) != 0 {
+				panic(fmt.Errorf("lac: NewSliceAligned: %v-byte pointer-holding elements cannot reach a %v-byte boundary natively", elemSz, align))
+			}
+			k := 0
+			for (addr+uintptr(k*elemSz))%uintptr(align) != 0 {
+				k++
+			}
+			return buf[k : k+len : k+cap]
+		}
+
+		// carve the aligned []T out of raw bytes, the same scheme as the
+		// arena path below. stepping whole elements cannot always reach the
+		// boundary: when elemSz is a multiple of align every element lands
+		// on the same residue as the base.
+		buf := make([]byte, cap*elemSz+align)
+		base := unsafe.Pointer(&buf[0])
+		pad := (uintptr(align) - uintptr(base)%uintptr(align)) % uintptr(align)
+		slice := (*sliceHeader)(unsafe.Pointer(&r))
+		slice.Data = unsafe.Add(base, pad)
+		slice.Len = int64(len)
+		slice.Cap = int64(cap)
+		return r
+	}
+
+	zero := mayContainsPtr(reflect.TypeOf(t).Elem().Kind())
+	raw := ac.alloc(cap*elemSz+align-ptrSize, zero)
+	pad := (uintptr(align) - uintptr(raw)%uintptr(align)) % uintptr(align)
+
+	slice := (*sliceHeader)(unsafe.Pointer(&r))
+	slice.Data = unsafe.Add(raw, pad)
+	slice.Len = int64(len)
+	slice.Cap = int64(cap)
+	if ac.DbgAllocTrace != nil {
+		ac.DbgAllocTrace(cap*elemSz, reflect.TypeOf(r))
+	}
 	return r
 }
 
@@ -196,15 +735,30 @@ func Append[T any](ac *Allocator, s []T, elems ...T) []T {
 			}
 		}
 
+		// past the configured step, grow linearly instead of near-doubling,
+		// so huge slices don't spike and discard enormous large chunks.
+		if step := ac.maxGrowStep; step > 0 {
+			if maxCap := pre.Cap + int64(step/elemSz); h.Cap > maxCap {
+				h.Cap = max(maxCap, pre.Cap+int64(len(elems)))
+			}
+		}
+
 		if h.Cap < 16 {
 			h.Cap = 16
 		}
 
-		sz := int(h.Cap) * elemSz
-		h.Data = ac.alloc(sz, false)
-		memmoveNoHeapPointers(h.Data, pre.Data, uintptr(int(pre.Len)*elemSz))
+		// a backing that just crossed ChunkSize gets rounded back to exactly
+		// one chunk while the appended data still fits in it: that backing
+		// fills a poolable chunk recycled on reset, where a slightly larger
+		// one costs a dedicated chunk discarded wholesale.
+		chunkSz := int64(ac.acPool.chunkPool.ChunkSize)
+		if bytes := h.Cap * int64(elemSz); bytes > chunkSz && bytes <= chunkSz+chunkSz/2 {
+			if minCap := pre.Cap + int64(len(elems)); minCap*int64(elemSz) <= chunkSz {
+				h.Cap = chunkSz / int64(elemSz)
+			}
+		}
 
-		// clear the reset part
+		sz := int(h.Cap) * elemSz
 
 		// FIX: invalid pointer in the allocated memory may cause panic in the write barrier.
 		var t *T
@@ -212,9 +766,50 @@ func Append[T any](ac *Allocator, s []T, elems ...T) []T {
 		if !BugfixClearPointerInMem {
 			zero = false
 		}
-		if zero {
-			used := elemSz * int(pre.Len)
-			memclrNoHeapPointers(unsafe.Add(h.Data, used), uintptr(sz-used))
+
+		// if the backing is the most recent allocation of the current chunk and
+		// the chunk still has room, extend in place by bumping the chunk further,
+		// avoiding the new block and the copy.
+		// single-threaded only: the shared mode may race on the chunk tail.
+		if ac.growSliceInPlace(&pre, elemSz, sz, zero) {
+			h.Data = pre.Data
+		} else {
+			h.Data = ac.alloc(sz, false)
+			if ac.DbgAllocTrace != nil {
+				ac.DbgAllocTrace(sz, reflect.TypeOf(s))
+			}
+			memmoveNoHeapPointers(h.Data, pre.Data, uintptr(int(pre.Len)*elemSz))
+
+			// clear the reset part
+			if zero {
+				used := elemSz * int(pre.Len)
+				memclrNoHeapPointers(unsafe.Add(h.Data, used), uintptr(sz-used))
+			}
+		}
+	}
+
+	// interface elems are stored as their full two words; the data word may
+	// point to a GC-managed box (or a heap pointee), invisible once written
+	// into arena memory, so register it for the cycle like Attach does.
+	switch reflect.TypeOf((*T)(nil)).Elem().Kind() {
+	case reflect.Interface:
+		for _, e := range elems {
+			if d := data(e); d != nil {
+				ac.externalPtr.Put(d)
+			}
+		}
+	case reflect.Ptr:
+		// a pointer element of another arena dangles once that arena resets.
+		// catch the aliasing at append time, where it is usually introduced,
+		// instead of at the next full check. heap pointers must be Attached
+		// before the append.
+		if ac.acPool.debugMode {
+			for _, e := range elems {
+				p := uintptr(data(e))
+				if p != 0 && ac.checkPointerType(p) == pointerTypeExternal {
+					errorf(ac.acPool, "potential bug: appending pointer %x owned by neither this allocator nor a registered external (foreign arena?)", p)
+				}
+			}
 		}
 	}
 
@@ -226,6 +821,262 @@ func Append[T any](ac *Allocator, s []T, elems ...T) []T {
 	return s
 }
 
+// NewAppender returns a pair of closures building an arena slice in a tight
+// inner loop: append writes straight into pre-reserved backing and only
+// falls into the grow path when cap runs out, done returns the built slice.
+// the closures capture the backing directly, so per-element cost is one
+// length check instead of Append's full header and grow logic.
+// NOTE: not safe for concurrent appends, and the slice must not be used
+// before done.
+func NewAppender[T any](ac *Allocator, n int) (appendFn func(T), done func() []T) {
+	s := NewSlice[T](ac, 0, n)
+	appendFn = func(v T) {
+		if len(s) < cap(s) {
+			s = s[:len(s)+1]
+			s[len(s)-1] = v
+		} else {
+			s = Append(ac, s, v)
+		}
+	}
+	done = func() []T {
+		return s
+	}
+	return
+}
+
+// GrowSlice ensures s has capacity for at least extra more elements, like
+// slices.Grow but arena-backed: the length stays the same, so a known burst
+// of appends can be served without incremental grows.
+func GrowSlice[T any](ac *Allocator, s []T, extra int) []T {
+	if extra <= 0 || cap(s)-len(s) >= extra {
+		return s
+	}
+	if ac == nil {
+		return append(s, make([]T, extra)...)[:len(s)]
+	}
+
+	h := (*sliceHeader)(unsafe.Pointer(&s))
+	pre := *h
+	var t *T
+	elemSz := int(unsafe.Sizeof(*t))
+	h.Cap = pre.Len + int64(extra)
+	sz := int(h.Cap) * elemSz
+
+	// FIX: invalid pointer in the allocated memory may cause panic in the write barrier.
+	zero := mayContainsPtr(reflect.TypeOf(t).Elem().Kind())
+	if !BugfixClearPointerInMem {
+		zero = false
+	}
+
+	if ac.growSliceInPlace(&pre, elemSz, sz, zero) {
+		h.Data = pre.Data
+	} else {
+		h.Data = ac.alloc(sz, false)
+		if ac.DbgAllocTrace != nil {
+			ac.DbgAllocTrace(sz, reflect.TypeOf(s))
+		}
+		memmoveNoHeapPointers(h.Data, pre.Data, uintptr(int(pre.Len)*elemSz))
+
+		// clear the reset part
+		if zero {
+			used := elemSz * int(pre.Len)
+			memclrNoHeapPointers(unsafe.Add(h.Data, used), uintptr(sz-used))
+		}
+	}
+	return s
+}
+
+// FreeLast reclaims a tentative allocation: if (ptr, size) is exactly the
+// most recent allocation of the current chunk, the used mark is rewound so
+// the next allocation reuses the space. anything else is a no-op (reported
+// in debug mode): linear allocation cannot free from the middle.
+// single-threaded only, the shared mode may race on the chunk tail.
+// NOTE: the caller must drop every reference to the freed object; a New'd
+// object handed back here is still scanned by the debug checker.
+func (ac *Allocator) FreeLast(ptr unsafe.Pointer, size int) {
+	if ac == nil || ptr == nil {
+		return
+	}
+	if ac.refCnt.Load() != 1 || ac.curChunk == nil {
+		return
+	}
+	ck := (*sliceHeader)(ac.curChunk)
+	szAligned := int64(alignSize(size))
+	if uintptr(ptr)+uintptr(szAligned) != uintptr(ck.Data)+uintptr(ck.Len) {
+		if ac.acPool.debugMode {
+			errorf(ac.acPool, "potential bug: FreeLast(%p, %v) is not the last allocation", ptr, size)
+		}
+		return
+	}
+	ck.Len -= szAligned
+
+	// drop the overlap record too, or the reusing allocation is a false hit.
+	if DbgCheckOverlap && ac.acPool.debugMode {
+		ac.regionLock.Lock()
+		if n := len(ac.regions); n > 0 && ac.regions[n-1].start == uintptr(ptr) {
+			ac.regions = ac.regions[:n-1]
+		}
+		ac.regionLock.Unlock()
+	}
+}
+
+// ExtendZero grows s by n zero elements in one step, the arena analog of
+// s = append(s, make([]T, n)...) minus the heap-made temporary. the new
+// elements are always zeroed, which pointer-carrying types need for GC
+// safety and everything else gets for the std-append parity.
+func ExtendZero[T any](ac *Allocator, s *[]T, n int) {
+	if n <= 0 {
+		return
+	}
+	if ac == nil {
+		*s = append(*s, make([]T, n)...)
+		return
+	}
+
+	*s = GrowSlice(ac, *s, n)
+	h := (*sliceHeader)(unsafe.Pointer(s))
+	var t *T
+	elemSz := int(unsafe.Sizeof(*t))
+	memclrNoHeapPointers(unsafe.Add(h.Data, int(h.Len)*elemSz), uintptr(n*elemSz))
+	h.Len += int64(n)
+}
+
+// NewSliceOfNew allocates a []*T whose n elements point at n zeroed,
+// contiguous T objects from the arena: batch locality of one block plus
+// ready-to-use non-nil pointers, replacing the NewSlice + New-per-index loop.
+func NewSliceOfNew[T any](ac *Allocator, n int) []*T {
+	if n <= 0 {
+		return nil
+	}
+	if ac == nil {
+		r := make([]*T, n)
+		for i := range r {
+			r[i] = new(T)
+		}
+		return r
+	}
+
+	r := NewSlice[*T](ac, n, n)
+	var t *T
+	sz := alignSize(int(unsafe.Sizeof(*t)))
+	block := ac.alloc(sz*n, true)
+	if ac.DbgAllocTrace != nil {
+		ac.DbgAllocTrace(sz*n, reflect.TypeOf(t).Elem())
+	}
+	for i := 0; i < n; i++ {
+		r[i] = (*T)(unsafe.Add(block, i*sz))
+	}
+	if ac.acPool.debugMode {
+		if reflect.TypeOf(t).Elem().Kind() == reflect.Struct {
+			for _, p := range r {
+				ac.debugScan(p)
+			}
+		}
+	}
+	return r
+}
+
+// NewRepeated allocates a pb-style repeated message field, e.g. Items []*PbItem:
+// the n messages come from one contiguous block with the []*T pre-pointed at
+// them. it is NewSliceOfNew under the name the pb use case reads naturally.
+func NewRepeated[T any](ac *Allocator, n int) []*T {
+	return NewSliceOfNew[T](ac, n)
+}
+
+// NewJagged builds a [][]T with one outer slice and, for each i, an inner
+// backing with caps[i] capacity and zero length, all allocated up front.
+// the inner slices are ready for Append without further reallocation.
+func NewJagged[T any](ac *Allocator, caps []int) [][]T {
+	if len(caps) == 0 {
+		return nil
+	}
+	r := NewSlice[[]T](ac, len(caps), len(caps))
+	for i, c := range caps {
+		r[i] = NewSlice[T](ac, 0, c)
+	}
+	return r
+}
+
+// NewAlignedFloat64Slice allocates a []float64 whose backing is aligned to
+// align bytes (a power of two), as required by SIMD kernels: 32 for AVX,
+// 64 for AVX-512. misalignment there costs either a crash or a slow path.
+func NewAlignedFloat64Slice(ac *Allocator, n, align int) []float64 {
+	if align <= 0 || align&(align-1) != 0 {
+		panic("NewAlignedFloat64Slice: align must be a power of two")
+	}
+	if n <= 0 {
+		return nil
+	}
+	if align < ptrSize {
+		align = ptrSize
+	}
+
+	if ac == nil {
+		buf := make([]float64, n+align/8)
+		addr := uintptr(unsafe.Pointer(&buf[0]))
+		off := int((uintptr(align)-addr%uintptr(align))%uintptr(align)) / 8
+		return buf[off : off+n : off+n]
+	}
+
+	// alloc guarantees pointer alignment, over-allocate the rest of the pad.
+	raw := ac.alloc(n*8+align-ptrSize, false)
+	pad := (uintptr(align) - uintptr(raw)%uintptr(align)) % uintptr(align)
+
+	var r []float64
+	h := (*sliceHeader)(unsafe.Pointer(&r))
+	h.Data = unsafe.Add(raw, pad)
+	h.Len = int64(n)
+	h.Cap = int64(n)
+	return r
+}
+
+// Float constrains the element type of numeric kernel slices.
+type Float interface {
+	~float32 | ~float64
+}
+
+// NewNumericSlice allocates a zeroed []T for SIMD kernels: the backing is
+// aligned to align bytes (a power of two) and padded up to the next multiple
+// of widthMultiple elements. the slice has logical length n but caps at the
+// padded length, so a full-width tail load through s[:cap(s)] stays inside
+// owned, zeroed memory and never faults; the pad may also be scribbled on.
+func NewNumericSlice[T Float](ac *Allocator, n, align, widthMultiple int) []T {
+	if align <= 0 || align&(align-1) != 0 {
+		panic("NewNumericSlice: align must be a power of two")
+	}
+	if n <= 0 {
+		return nil
+	}
+	if widthMultiple <= 0 {
+		widthMultiple = 1
+	}
+	if align < ptrSize {
+		align = ptrSize
+	}
+
+	var t T
+	sz := int(unsafe.Sizeof(t))
+	padded := (n + widthMultiple - 1) / widthMultiple * widthMultiple
+
+	if ac == nil {
+		buf := make([]T, padded+align/sz)
+		addr := uintptr(unsafe.Pointer(&buf[0]))
+		off := int((uintptr(align)-addr%uintptr(align))%uintptr(align)) / sz
+		return buf[off : off+n : off+padded]
+	}
+
+	// alloc guarantees pointer alignment, over-allocate the rest of the pad.
+	raw := ac.alloc(padded*sz+align-ptrSize, true)
+	pad := (uintptr(align) - uintptr(raw)%uintptr(align)) % uintptr(align)
+
+	var r []T
+	h := (*sliceHeader)(unsafe.Pointer(&r))
+	h.Data = unsafe.Add(raw, pad)
+	h.Len = int64(n)
+	h.Cap = int64(padded)
+	return r
+}
+
 func NewMap[K comparable, V any](ac *Allocator, cap int) map[K]V {
 	m := make(map[K]V, cap)
 	if ac == nil {
@@ -235,6 +1086,23 @@ func NewMap[K comparable, V any](ac *Allocator, cap int) map[K]V {
 	return m
 }
 
+// MapSetString inserts v under an arena copy of k, so the map holds key
+// bytes from the arena instead of keeping the caller's backing alive — the
+// fix for maps built from parsed tokens whose source buffer is recycled or
+// mutated later: the stored key stays stable however the source changes.
+// the key data consequently dies with the cycle, so the map must be one made
+// from this allocator (see NewMap) and released with it, never outlive it.
+func MapSetString[T any](ac *Allocator, m map[string]*T, k string, v *T) {
+	m[ac.NewString(k)] = v
+}
+
+// NewNestedMap allocates the outer map of a map[K1]map[K2]V and registers it
+// for keep-alive. the inner maps must be created with NewMap as well, so the
+// debug check can validate every level against the tracked maps.
+func NewNestedMap[K1, K2 comparable, V any](ac *Allocator, cap int) map[K1]map[K2]V {
+	return NewMap[K1, map[K2]V](ac, cap)
+}
+
 func NewEnum[T any](ac *Allocator, e T) *T {
 	if ac == nil {
 		r := new(T)
@@ -246,6 +1114,37 @@ func NewEnum[T any](ac *Allocator, e T) *T {
 	return r
 }
 
+// Enum is the constraint for enum underlying types.
+type Enum interface {
+	~int8 | ~int16 | ~int32 | ~int64 | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// NewEnumSlice batch-allocates the enum values and the pointer array in one go,
+// both from lac, to avoid appending the enum pointers one by one.
+func NewEnumSlice[T Enum](ac *Allocator, vals ...T) []*T {
+	if ac == nil {
+		r := make([]*T, len(vals))
+		for i := range vals {
+			v := vals[i]
+			r[i] = &v
+		}
+		return r
+	}
+
+	r := NewSlice[*T](ac, len(vals), len(vals))
+	if len(vals) == 0 {
+		return r
+	}
+	var t T
+	store := (*T)(ac.alloc(len(vals)*int(unsafe.Sizeof(t)), false))
+	s := unsafe.Slice(store, len(vals))
+	for i, v := range vals {
+		s[i] = v
+		r[i] = &s[i]
+	}
+	return r
+}
+
 func (ac *Allocator) NewString(v string) string {
 	if ac == nil {
 		return v
@@ -262,6 +1161,234 @@ func (ac *Allocator) NewString(v string) string {
 	return v
 }
 
+// NewStringFromRunes UTF-8 encodes runes directly into one arena allocation
+// sized to the exact byte length, skipping the intermediate heap string a
+// string([]rune) conversion would make.
+func (ac *Allocator) NewStringFromRunes(runes []rune) string {
+	if ac == nil {
+		return string(runes)
+	}
+	if len(runes) == 0 {
+		return ""
+	}
+
+	total := 0
+	for _, c := range runes {
+		if n := utf8.RuneLen(c); n > 0 {
+			total += n
+		} else {
+			// invalid runes encode as RuneError, like string([]rune).
+			total += utf8.RuneLen(utf8.RuneError)
+		}
+	}
+
+	var r string
+	h := (*stringHeader)(unsafe.Pointer(&r))
+	h.Data = ac.alloc(total, false)
+	h.Len = total
+
+	b := unsafe.Slice((*byte)(h.Data), total)
+	off := 0
+	for _, c := range runes {
+		off += utf8.EncodeRune(b[off:], c)
+	}
+	return r
+}
+
+// NewStringf formats directly into arena memory through a reusable scratch
+// buffer, returning an arena string. unlike ac.String(fmt.Sprintf(...)) it
+// skips the intermediate heap string, which matters when formatting
+// thousands of small strings per cycle.
+func (ac *Allocator) NewStringf(format string, args ...interface{}) string {
+	if ac == nil {
+		return fmt.Sprintf(format, args...)
+	}
+
+	ac.fmtLock.Lock()
+	ac.fmtBuf.Reset()
+	_, _ = fmt.Fprintf(&ac.fmtBuf, format, args...)
+	b := ac.fmtBuf.Bytes()
+
+	var r string
+	if len(b) > 0 {
+		h := (*stringHeader)(unsafe.Pointer(&r))
+		h.Data = ac.alloc(len(b), false)
+		h.Len = len(b)
+		memmoveNoHeapPointers(h.Data, unsafe.Pointer(&b[0]), uintptr(len(b)))
+	}
+	ac.fmtLock.Unlock()
+	return r
+}
+
+// NewStrings packs vals into the arena as one []string whose string bytes
+// share a single contiguous region, each header pointing into it.
+// tighter and more cache-friendly than one NewString per element.
+func (ac *Allocator) NewStrings(vals ...string) []string {
+	if ac == nil {
+		return vals
+	}
+	if len(vals) == 0 {
+		return nil
+	}
+
+	total := 0
+	for _, v := range vals {
+		total += len(v)
+	}
+
+	r := NewSlice[string](ac, len(vals), len(vals))
+	bytes := ac.alloc(total, false)
+
+	off := 0
+	for i, v := range vals {
+		if len(v) == 0 {
+			r[i] = ""
+			continue
+		}
+		dst := unsafe.Add(bytes, off)
+		memmoveNoHeapPointers(dst, (*stringHeader)(unsafe.Pointer(&v)).Data, uintptr(len(v)))
+		h := (*stringHeader)(unsafe.Pointer(&r[i]))
+		h.Data = dst
+		h.Len = len(v)
+		off += len(v)
+	}
+	return r
+}
+
+// SwapBacking swaps the backings (data, len and cap) of two arena slices
+// while the header variables stay put, e.g. the front and back buffer of a
+// double-buffered pipeline. debug mode asserts both backings are arena-owned,
+// a heap backing swapped in here would silently escape the cycle.
+func SwapBacking[T any](ac *Allocator, a, b *[]T) {
+	if ac != nil && ac.acPool.debugMode {
+		for _, s := range [...]*[]T{a, b} {
+			h := (*sliceHeader)(unsafe.Pointer(s))
+			if h.Data != nil && ac.checkPointerType(uintptr(h.Data)) == pointerTypeExternal {
+				errorf(ac.acPool, "potential bug: SwapBacking with a backing not owned by this allocator")
+			}
+		}
+	}
+	*a, *b = *b, *a
+}
+
+// SetLen resizes an arena slice within its owned backing, the checked
+// counterpart of an unsafe header poke. n must be within [0, cap].
+func SetLen[T any](ac *Allocator, s *[]T, n int) {
+	h := (*sliceHeader)(unsafe.Pointer(s))
+	if n < 0 || int64(n) > h.Cap {
+		if ac == nil {
+			panic(fmt.Errorf("potential bug: SetLen(%v) out of cap %v", n, h.Cap))
+		}
+		errorf(ac.acPool, "potential bug: SetLen(%v) out of cap %v", n, h.Cap)
+		return
+	}
+	h.Len = int64(n)
+}
+
+// SetCap shrinks an arena slice's capacity, e.g. to stop a later Append
+// from growing into a region handed out to someone else. the cap can only
+// shrink: the header knows nothing beyond its current backing.
+func SetCap[T any](ac *Allocator, s *[]T, n int) {
+	h := (*sliceHeader)(unsafe.Pointer(s))
+	if int64(n) > h.Cap || n < 0 {
+		if ac == nil {
+			panic(fmt.Errorf("potential bug: SetCap(%v) beyond owned cap %v", n, h.Cap))
+		}
+		errorf(ac.acPool, "potential bug: SetCap(%v) beyond owned cap %v", n, h.Cap)
+		return
+	}
+	h.Cap = int64(n)
+	if h.Len > h.Cap {
+		h.Len = h.Cap
+	}
+}
+
+// AssertPacked panics unless struct type t is free of compiler-inserted
+// padding: its size must equal the sum of its field sizes, recursively for
+// nested structs and struct arrays. run it at startup for wire-format
+// structs before viewing raw bytes through them with CastSlice — hidden
+// padding would silently shift every following field, and the layout can
+// differ across architectures.
+func AssertPacked(t reflect.Type) {
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Errorf("lac: AssertPacked: %v is not a struct", t))
+	}
+	var sum uintptr
+	for i := 0; i < t.NumField(); i++ {
+		ft := t.Field(i).Type
+		switch {
+		case ft.Kind() == reflect.Struct:
+			AssertPacked(ft)
+		case ft.Kind() == reflect.Array && ft.Elem().Kind() == reflect.Struct:
+			AssertPacked(ft.Elem())
+		}
+		sum += ft.Size()
+	}
+	if sum != t.Size() {
+		panic(fmt.Errorf("lac: %v has %v padding bytes (size %v, field sum %v), not safe as a wire format", t, t.Size()-sum, t.Size(), sum))
+	}
+}
+
+// CastSlice views an arena []byte as a []T without copying, e.g. a packed
+// binary format read straight off an arena buffer. len(b) must be a multiple
+// of T's size, b must be aligned for T, and T must be pointerless: pointers
+// smuggled in through a byte view would be invisible to the GC and the
+// pointer checker. misuse is reported via errorf.
+func CastSlice[T any](ac *Allocator, b []byte) []T {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	elemSz := int64(typ.Size())
+	if ContainsPointers(typ) {
+		errorf(ac.acPool, "potential bug: CastSlice to %v, which contains pointers", typ)
+		return nil
+	}
+	h := (*sliceHeader)(unsafe.Pointer(&b))
+	if elemSz == 0 || h.Len%elemSz != 0 {
+		errorf(ac.acPool, "potential bug: CastSlice: len %v is not a multiple of %v's size %v", h.Len, typ, elemSz)
+		return nil
+	}
+	if h.Data != nil && uintptr(h.Data)%uintptr(typ.Align()) != 0 {
+		errorf(ac.acPool, "potential bug: CastSlice: backing not aligned for %v", typ)
+		return nil
+	}
+	var r []T
+	rh := (*sliceHeader)(unsafe.Pointer(&r))
+	rh.Data = h.Data
+	rh.Len = h.Len / elemSz
+	rh.Cap = h.Cap / elemSz
+	return r
+}
+
+// StorePtrInternal writes v into dst without any keep-alive bookkeeping.
+// it is the fast counterpart of Attach for pointees guaranteed to be
+// arena-resident: debug mode asserts that, release mode is a plain store.
+func StorePtrInternal[T any](ac *Allocator, dst **T, v *T) {
+	if ac != nil && v != nil && ac.acPool.debugMode {
+		if ac.checkPointerType(uintptr(unsafe.Pointer(v))) == pointerTypeExternal {
+			errorf(ac.acPool, "potential bug: StorePtrInternal with external pointer: %p", v)
+		}
+	}
+	*dst = v
+}
+
+// AsInterface boxes the arena pointer p into an interface by writing the
+// eface words directly, so no call shape can make the concrete value escape
+// and re-box on the heap. the interface's data word keeps p reachable.
+// external pointers are still registered for keep-alive in debug mode.
+func AsInterface[T any](ac *Allocator, p *T) interface{} {
+	if p == nil {
+		return nil
+	}
+	if ac != nil && ac.acPool.debugMode {
+		if ac.checkPointerType(uintptr(unsafe.Pointer(p))) == pointerTypeExternal {
+			ac.keepAlive(p)
+		}
+	}
+
+	var r interface{} = (*T)(nil)
+	(*emptyInterface)(unsafe.Pointer(&r)).Data = unsafe.Pointer(p)
+	return r
+}
+
 // Attach mark ptr as external pointer and will keep ptr alive during GC,
 // otherwise the ptr from heap may be GCed and cause a dangled pointer, no panic will report by the runtime.
 // So make sure to mark objects from native heap as external pointers by using this function.
@@ -328,6 +1455,10 @@ func (ac *Allocator) Uint32(v uint32) (r *uint32) {
 	return
 }
 
+// Int64 returns an 8-byte aligned pointer, so atomic operations
+// (e.g. atomic.AddInt64) on the returned pointer are always legal.
+// lac requires a 64bit platform (checked in init) and alloc rounds
+// every allocation to ptrSize, so the alignment is guaranteed.
 func (ac *Allocator) Int64(v int64) (r *int64) {
 	if ac == nil {
 		r = new(int64)
@@ -338,6 +1469,7 @@ func (ac *Allocator) Int64(v int64) (r *int64) {
 	return
 }
 
+// Uint64 returns an 8-byte aligned pointer, see Int64.
 func (ac *Allocator) Uint64(v uint64) (r *uint64) {
 	if ac == nil {
 		r = new(uint64)
@@ -281,3 +281,100 @@ func Benchmark_LacMallocLarge2Mt(t *testing.B) {
 	runtime.KeepAlive(e)
 	t.StopTimer()
 }
+
+func Benchmark_NewTiny(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ac := NewTiny()
+		New[PbItem](ac)
+		ac.Release()
+	}
+}
+
+func Benchmark_NewLac(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ac := newLac(acPool)
+		New[PbItem](ac)
+		ac.Release()
+	}
+}
+
+func benchmarkChunkGrowth(b *testing.B, geometric bool) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ac := acPool.Get()
+		if geometric {
+			ac.SetGeometricChunks(true, 1<<22)
+		}
+		for j := 0; j < 1024; j++ {
+			NewSlice[byte](ac, 0, 1024)
+		}
+		ac.Release()
+	}
+}
+
+func Benchmark_FixedChunks(b *testing.B)     { benchmarkChunkGrowth(b, false) }
+func Benchmark_GeometricChunks(b *testing.B) { benchmarkChunkGrowth(b, true) }
+
+// the legacy reflection Enum vs the generic NewEnum on a per-item hot path.
+func Benchmark_EnumReflection(b *testing.B) {
+	ac := acPool.Get()
+	defer ac.Release()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if v := ac.Enum(EnumVal2).(*EnumA); *v != EnumVal2 {
+			b.Fail()
+		}
+		if i%1000 == 999 {
+			ac.Reset()
+		}
+	}
+}
+
+func Benchmark_NewEnum(b *testing.B) {
+	ac := acPool.Get()
+	defer ac.Release()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if v := NewEnum(ac, EnumVal2); *v != EnumVal2 {
+			b.Fail()
+		}
+		if i%1000 == 999 {
+			ac.Reset()
+		}
+	}
+}
+
+// the appender's closure-captured backing vs the general Append on a
+// 1000-element build.
+func Benchmark_SliceAppend(b *testing.B) {
+	ac := acPool.Get()
+	defer ac.Release()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var s []int
+		for j := 0; j < 1000; j++ {
+			SliceAppend(ac, &s, j)
+		}
+		ac.Reset()
+	}
+}
+
+func Benchmark_NewAppender(b *testing.B) {
+	ac := acPool.Get()
+	defer ac.Release()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		add, done := NewAppender[int](ac, 1000)
+		for j := 0; j < 1000; j++ {
+			add(j)
+		}
+		_ = done()
+		ac.Reset()
+	}
+}
@@ -12,7 +12,9 @@ package lac
 import (
 	"fmt"
 	"os"
+	"reflect"
 	"runtime"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -173,6 +175,21 @@ func Test_NewSlice(t *testing.T) {
 		t.Fail()
 	}
 
+	// elements larger than a pointer must not overrun the backing.
+	bigSlice := NewSlice[Data](ac, 4, 4)
+	next := ac.Int64(0)
+	for i := range bigSlice {
+		bigSlice[i] = Data{d: [2]uint64{d1, d2}}
+	}
+	if *next != 0 {
+		t.Errorf("allocation overrun")
+	}
+	for i := range bigSlice {
+		if bigSlice[i].d[0] != d1 || bigSlice[i].d[1] != d2 {
+			t.Fail()
+		}
+	}
+
 	f := func() []int {
 		var r []int = NewSlice[int](ac, 0, 1)
 		r = Append(ac, r, 1)
@@ -290,6 +307,395 @@ func Test_Enum(t *testing.T) {
 	}
 }
 
+func Test_EnumSlice(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	s := NewEnumSlice(ac, EnumVal1, EnumVal2, EnumVal1)
+	if len(s) != 3 {
+		t.Fail()
+	}
+	if *s[0] != EnumVal1 || *s[1] != EnumVal2 || *s[2] != EnumVal1 {
+		t.Errorf("values")
+	}
+	for _, p := range s {
+		if ac.checkPointerType(uintptr(unsafe.Pointer(p))) != pointerTypeLacInternal {
+			t.Errorf("enum value not in lac")
+		}
+	}
+	if ac.checkPointerType(uintptr((*sliceHeader)(unsafe.Pointer(&s)).Data)) != pointerTypeLacInternal {
+		t.Errorf("pointer array not in lac")
+	}
+
+	e := NewEnumSlice[EnumA](nil, EnumVal2)
+	if len(e) != 1 || *e[0] != EnumVal2 {
+		t.Fail()
+	}
+}
+
+func Test_AllocTrace(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	type traced struct {
+		sz  int
+		typ reflect.Type
+	}
+	var log []traced
+	ac.DbgAllocTrace = func(size int, typ reflect.Type) {
+		log = append(log, traced{size, typ})
+	}
+
+	_ = New[PbItem](ac)
+	_ = NewSlice[int](ac, 0, 4)
+
+	if len(log) != 2 {
+		t.Errorf("trace cnt: %v", len(log))
+	}
+	if log[0].typ != reflect.TypeOf(PbItem{}) || log[0].sz != int(unsafe.Sizeof(PbItem{})) {
+		t.Errorf("trace 0: %+v", log[0])
+	}
+	if log[1].typ != reflect.TypeOf([]int{}) {
+		t.Errorf("trace 1: %+v", log[1])
+	}
+}
+
+func Test_NewAlignedFloat64Slice(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	for _, align := range []int{8, 16, 32, 64} {
+		for i := 1; i < 100; i++ {
+			s := NewAlignedFloat64Slice(ac, i, align)
+			if len(s) != i {
+				t.Fatalf("len: %v", len(s))
+			}
+			if addr := uintptr(unsafe.Pointer(&s[0])); addr%uintptr(align) != 0 {
+				t.Fatalf("align %v, n %v: addr %x", align, i, addr)
+			}
+			s[0], s[i-1] = 1, 2
+		}
+	}
+
+	// native fallback aligns too.
+	s := NewAlignedFloat64Slice(nil, 8, 64)
+	if uintptr(unsafe.Pointer(&s[0]))%64 != 0 {
+		t.Errorf("native not aligned")
+	}
+
+	if NewAlignedFloat64Slice(ac, 0, 32) != nil {
+		t.Errorf("empty")
+	}
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("bad align not reported")
+		}
+	}()
+	NewAlignedFloat64Slice(ac, 1, 24)
+}
+
+func Test_NewSliceOfNew(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	defer acPool.EnableDebugMode(false)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	nodes := NewSliceOfNew[PbItem](ac, 10)
+	if len(nodes) != 10 {
+		t.Fatalf("len: %v", len(nodes))
+	}
+
+	stride := alignSize(int(unsafe.Sizeof(PbItem{})))
+	for i, p := range nodes {
+		if p == nil {
+			t.Fatalf("nil elem %v", i)
+		}
+		if *p != (PbItem{}) {
+			t.Errorf("elem %v not zeroed", i)
+		}
+		if i > 0 {
+			prev := uintptr(unsafe.Pointer(nodes[i-1]))
+			if uintptr(unsafe.Pointer(p))-prev != uintptr(stride) {
+				t.Errorf("elem %v not contiguous", i)
+			}
+		}
+		p.Id = ac.Int(i)
+	}
+
+	if NewSliceOfNew[PbItem](ac, 0) != nil {
+		t.Errorf("empty")
+	}
+
+	n := NewSliceOfNew[PbItem](nil, 2)
+	if len(n) != 2 || n[0] == nil || n[1] == nil {
+		t.Errorf("native: %v", n)
+	}
+}
+
+func Test_NewJagged(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	caps := []int{3, 0, 5}
+	j := NewJagged[int](ac, caps)
+	if len(j) != len(caps) {
+		t.Fatalf("outer len: %v", len(j))
+	}
+	for i, c := range caps {
+		if len(j[i]) != 0 || cap(j[i]) != c {
+			t.Errorf("inner %v: len %v cap %v", i, len(j[i]), cap(j[i]))
+		}
+	}
+
+	// filling up to cap must not move the backings.
+	d0 := (*sliceHeader)(unsafe.Pointer(&j[0])).Data
+	for i := 0; i < 3; i++ {
+		j[0] = Append(ac, j[0], i)
+	}
+	if (*sliceHeader)(unsafe.Pointer(&j[0])).Data != d0 {
+		t.Errorf("inner backing moved")
+	}
+	for i, v := range j[0] {
+		if v != i {
+			t.Errorf("elem %v: %v", i, v)
+		}
+	}
+
+	if NewJagged[int](ac, nil) != nil {
+		t.Errorf("empty caps")
+	}
+}
+
+func Test_NativeMode(t *testing.T) {
+	ac := Native()
+	if ac.IsLinear() {
+		t.Errorf("native ac claims linear")
+	}
+	if !strings.Contains(ac.Stats(), "native") {
+		t.Errorf("stats: %v", ac.Stats())
+	}
+
+	// works as the usual nil fallback.
+	item := New[PbItem](ac)
+	item.Id = ac.Int(1)
+	if *item.Id != 1 {
+		t.Fail()
+	}
+
+	real_ := acPool.Get()
+	defer real_.Release()
+	if !real_.IsLinear() {
+		t.Errorf("pooled ac claims native")
+	}
+	_ = New[PbItem](real_)
+	if !strings.Contains(real_.Stats(), "linear") {
+		t.Errorf("stats: %v", real_.Stats())
+	}
+}
+
+func Test_NewStringf(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	for i := 0; i < 1000; i++ {
+		s := ac.NewStringf("str%v", i)
+		if s != fmt.Sprintf("str%v", i) {
+			t.Fatalf("formatted: %q", s)
+		}
+		if ac.checkPointerType(uintptr((*stringHeader)(unsafe.Pointer(&s)).Data)) != pointerTypeLacInternal {
+			t.Fatalf("bytes not in lac: %q", s)
+		}
+	}
+
+	if ac.NewStringf("") != "" {
+		t.Errorf("empty")
+	}
+	if Native().NewStringf("a%vb", 1) != "a1b" {
+		t.Errorf("native fallback")
+	}
+}
+
+func Test_NewStrings(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	src := []string{"hello", "", "lac", "world"}
+	s := ac.NewStrings(src...)
+
+	if len(s) != len(src) {
+		t.Fatalf("len: %v", len(s))
+	}
+	for i, v := range src {
+		if s[i] != v {
+			t.Errorf("elem %v: %q", i, s[i])
+		}
+	}
+
+	// headers and bytes are arena-backed, bytes contiguous.
+	if ac.checkPointerType(uintptr((*sliceHeader)(unsafe.Pointer(&s)).Data)) != pointerTypeLacInternal {
+		t.Errorf("headers not internal")
+	}
+	var prevEnd uintptr
+	for i, v := range s {
+		if len(v) == 0 {
+			continue
+		}
+		h := (*stringHeader)(unsafe.Pointer(&v))
+		if ac.checkPointerType(uintptr(h.Data)) != pointerTypeLacInternal {
+			t.Errorf("bytes of %v not internal", i)
+		}
+		if prevEnd != 0 && uintptr(h.Data) != prevEnd {
+			t.Errorf("bytes of %v not contiguous", i)
+		}
+		prevEnd = uintptr(h.Data) + uintptr(h.Len)
+	}
+
+	if ac.NewStrings() != nil {
+		t.Errorf("empty input")
+	}
+}
+
+func Test_GrowSlice(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	s := NewSlice[int](ac, 0, 2)
+	s = Append(ac, s, 1, 2)
+	s = GrowSlice(ac, s, 100)
+
+	if len(s) != 2 || cap(s) < 102 {
+		t.Errorf("len %v cap %v", len(s), cap(s))
+	}
+	if s[0] != 1 || s[1] != 2 {
+		t.Errorf("elems lost: %v", s)
+	}
+	if ac.checkPointerType(uintptr((*sliceHeader)(unsafe.Pointer(&s)).Data)) != pointerTypeLacInternal {
+		t.Errorf("not arena backed")
+	}
+
+	// the reserved room must absorb the burst without moving the backing.
+	d := (*sliceHeader)(unsafe.Pointer(&s)).Data
+	for i := 0; i < 100; i++ {
+		s = Append(ac, s, i)
+	}
+	if (*sliceHeader)(unsafe.Pointer(&s)).Data != d {
+		t.Errorf("backing moved")
+	}
+
+	// enough headroom already: no-op.
+	if s2 := GrowSlice(ac, s, 0); (*sliceHeader)(unsafe.Pointer(&s2)).Data != d {
+		t.Errorf("no-op grow moved")
+	}
+
+	// native fallback.
+	n := GrowSlice[int](nil, []int{1}, 8)
+	if len(n) != 1 || cap(n) < 9 || n[0] != 1 {
+		t.Errorf("native: len %v cap %v", len(n), cap(n))
+	}
+}
+
+func Test_Sampling(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	ac.EnableSampling(8)
+	for i := 0; i < 800; i++ {
+		_ = New[PbItem](ac)
+	}
+
+	samples := ac.SampledAllocs()
+	// 800 allocations at rate 8: expect roughly 100 samples.
+	if n := len(samples); n < 50 || n > 200 {
+		t.Errorf("sample cnt: %v", n)
+	}
+	for _, s := range samples {
+		if s.Size != int(unsafe.Sizeof(PbItem{})) {
+			t.Errorf("sample size: %+v", s)
+		}
+		if !strings.Contains(s.Caller, "api_test.go:") {
+			t.Errorf("sample caller: %+v", s)
+		}
+	}
+
+	ac.EnableSampling(0)
+	_ = New[PbItem](ac)
+	if len(ac.SampledAllocs()) != 0 {
+		t.Errorf("not cleared")
+	}
+}
+
+func Test_AppendInPlace(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	s := NewSlice[int](ac, 0, 4)
+	s = Append(ac, s, 0, 1, 2, 3)
+	base := (*sliceHeader)(unsafe.Pointer(&s)).Data
+
+	// the backing is the tail of the chunk, growth should not move it.
+	s = Append(ac, s, 4)
+	if (*sliceHeader)(unsafe.Pointer(&s)).Data != base {
+		t.Errorf("should grow in place")
+	}
+	for i := 0; i < 5; i++ {
+		if s[i] != i {
+			t.Errorf("elem %v", i)
+		}
+	}
+
+	// another allocation after the slice blocks the in-place path.
+	s2 := NewSlice[*int](ac, 0, 1)
+	s2 = Append(ac, s2, ac.Int(1))
+	_ = New[PbItem](ac)
+	base2 := (*sliceHeader)(unsafe.Pointer(&s2)).Data
+	s2 = Append(ac, s2, ac.Int(2))
+	if (*sliceHeader)(unsafe.Pointer(&s2)).Data == base2 {
+		t.Errorf("should relocate")
+	}
+	if *s2[0] != 1 || *s2[1] != 2 {
+		t.Errorf("values")
+	}
+}
+
+func Test_AtomicOnLacInt64(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	for i := 0; i < 100; i++ {
+		ac.alloc(1, false) // shift the bump offset
+		p := ac.Int64(0)
+		if uintptr(unsafe.Pointer(p))%8 != 0 {
+			t.Errorf("misaligned int64")
+		}
+		atomic.AddInt64(p, 2)
+		if atomic.LoadInt64(p) != 2 {
+			t.Fail()
+		}
+	}
+}
+
+func Test_GlobalLimit(t *testing.T) {
+	defer SetGlobalLimit(0)
+
+	if TotalReserved() <= 0 {
+		t.Errorf("reserved: %v", TotalReserved())
+	}
+
+	// the test pool pre-reserves way beyond 1 byte.
+	SetGlobalLimit(1)
+	if ac := acPool.Get(); ac != nil {
+		ac.Release()
+		t.Errorf("should fall back to native mode")
+	}
+
+	SetGlobalLimit(0)
+	ac := acPool.Get()
+	if ac == nil {
+		t.Errorf("should be enabled again")
+	}
+	ac.Release()
+}
+
 func Test_AttachExternal(b *testing.T) {
 	ac := acPool.Get()
 	defer ac.Release()
@@ -349,6 +755,98 @@ func Test_AttachExternal(b *testing.T) {
 	}
 }
 
+func Test_AsInterface(t *testing.T) {
+	acPool.EnableDebugMode(false)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	item := New[PbItem](ac)
+	item.Id = ac.Int(7)
+
+	var i interface{}
+	noMalloc(func() {
+		i = AsInterface(ac, item)
+	})
+
+	p, ok := i.(*PbItem)
+	if !ok || p != item || *p.Id != 7 {
+		t.Errorf("boxed: %v", i)
+	}
+	if AsInterface[PbItem](ac, nil) != nil {
+		t.Errorf("nil not preserved")
+	}
+}
+
+func Test_LargeUnalignedAlloc(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	// an odd-sized allocation past ChunkSize must terminate: the dedicated
+	// chunk used to be sized to the unaligned need, which can never satisfy
+	// the aligned request, so alloc kept allocating chunks forever.
+	n := acPool.chunkPool.ChunkSize + 1
+	s := ac.NewString(strings.Repeat("a", n))
+	if len(s) != n {
+		t.Errorf("len: %v", len(s))
+	}
+}
+
+func Test_MaxGrowStep(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	const step = 16 * 1024
+	ac.SetMaxGrowStep(step)
+
+	var s []byte
+	prevCap := 0
+	for i := 0; i < 200*1024; i++ {
+		s = Append(ac, s, byte(i))
+		if c := cap(s); c != prevCap {
+			if grown := c - prevCap; grown > step {
+				t.Fatalf("grow step %v exceeds cap %v at len %v", grown, step, len(s))
+			}
+			prevCap = c
+		}
+	}
+	for i, v := range s {
+		if v != byte(i) {
+			t.Fatalf("elem %v corrupted", i)
+		}
+	}
+}
+
+func Test_AppendNilElem(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	defer acPool.EnableDebugMode(false)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	// both the untyped nil literal and a typed nil pointer must land as a
+	// plain nil element, and the debug check must accept them.
+	var s []*PbItem
+	s = Append(ac, s, nil)
+	s = Append(ac, s, (*PbItem)(nil))
+	if len(s) != 2 || s[0] != nil || s[1] != nil {
+		t.Errorf("nil elems: %v", s)
+	}
+
+	var s2 []*PbItem
+	SliceAppend(ac, &s2, nil)
+	SliceAppend(ac, &s2, (*PbItem)(nil))
+	if len(s2) != 2 || s2[0] != nil || s2[1] != nil {
+		t.Errorf("legacy nil elems: %v", s2)
+	}
+
+	// nil elements stay nil after a grow relocates the backing.
+	for i := 0; i < 100; i++ {
+		s = Append(ac, s, New[PbItem](ac))
+	}
+	if s[0] != nil || s[1] != nil {
+		t.Errorf("nil elems corrupted by grow")
+	}
+}
+
 func Test_Append(t *testing.T) {
 	ac := acPool.Get()
 	defer ac.Release()
@@ -622,7 +1120,7 @@ func TestReinitPool(t *testing.T) {
 	time.Sleep(time.Duration(1) * time.Microsecond)
 
 	acPoolMu.Lock()
-	acPool = NewAllocatorPool("test", nil, 10000, 64*1024, 32*1000, 64*1000)
+	acPool = newTestPool()
 	acPoolMu.Unlock()
 
 	// force to recycle the old pool.
@@ -702,3 +1200,933 @@ func TestFreeMarkedObj(t *testing.T) {
 		ac.reset()
 	}
 }
+
+func Test_NewRepeated(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	defer acPool.EnableDebugMode(false)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	d := New[PbData](ac)
+	d.Age = ac.Int(1)
+	d.Items = NewRepeated[PbItem](ac, 5)
+
+	stride := alignSize(int(unsafe.Sizeof(PbItem{})))
+	for i, item := range d.Items {
+		if item == nil {
+			t.Fatalf("nil item %v", i)
+		}
+		if i > 0 {
+			prev := uintptr(unsafe.Pointer(d.Items[i-1]))
+			if uintptr(unsafe.Pointer(item))-prev != uintptr(stride) {
+				t.Errorf("item %v not contiguous", i)
+			}
+		}
+		item.Id = ac.Int(i)
+		item.Name = ac.String("item")
+	}
+
+	ac.CheckExternalPointers()
+	for i, item := range d.Items {
+		if *item.Id != i || *item.Name != "item" {
+			t.Errorf("item %v corrupted", i)
+		}
+	}
+}
+
+func Test_SwapBacking(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	front := NewSlice[int](ac, 2, 8)
+	back := NewSlice[int](ac, 3, 16)
+	front[0] = 1
+	back[0] = 2
+
+	SwapBacking(ac, &front, &back)
+	if len(front) != 3 || cap(front) != 16 || front[0] != 2 {
+		t.Errorf("front: %v cap %v", front, cap(front))
+	}
+	if len(back) != 2 || cap(back) != 8 || back[0] != 1 {
+		t.Errorf("back: %v cap %v", back, cap(back))
+	}
+}
+
+func Test_SwapBackingExternal(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	defer acPool.EnableDebugMode(false)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	a := NewSlice[int](ac, 1, 1)
+	b := make([]int, 1)
+
+	defer func() {
+		if e := recover(); e == nil {
+			t.Errorf("should report external backing")
+		}
+	}()
+	SwapBacking(ac, &a, &b)
+}
+
+func Test_SetLenSetCap(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	s := NewSlice[int](ac, 0, 8)
+	SetLen(ac, &s, 4)
+	if len(s) != 4 {
+		t.Errorf("len: %v", len(s))
+	}
+
+	SetCap(ac, &s, 2)
+	if cap(s) != 2 || len(s) != 2 {
+		t.Errorf("cap: %v len: %v", cap(s), len(s))
+	}
+
+	// native-mode misuse panics with the diagnostic instead of a nil deref.
+	ns := make([]int, 0, 4)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expect panic")
+			}
+		}()
+		SetLen[int](nil, &ns, 5)
+	}()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expect panic")
+			}
+		}()
+		SetCap[int](nil, &ns, 5)
+	}()
+
+	defer func() {
+		if e := recover(); e == nil {
+			t.Errorf("should report out-of-cap len")
+		}
+	}()
+	SetLen(ac, &s, 3)
+}
+
+func Test_NewNumericSlice(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	for _, n := range []int{1, 3, 8, 100} {
+		s := NewNumericSlice[float32](ac, n, 64, 8)
+		if len(s) != n {
+			t.Fatalf("len: %v", len(s))
+		}
+		if cap(s)%8 != 0 || cap(s) < n {
+			t.Errorf("cap %v not padded to width multiple", cap(s))
+		}
+		if uintptr(unsafe.Pointer(&s[0]))%64 != 0 {
+			t.Errorf("backing not 64-byte aligned")
+		}
+		// the padding contract: a full-width tail read stays in bounds.
+		pad := s[:cap(s)]
+		for i := n; i < len(pad); i++ {
+			if pad[i] != 0 {
+				t.Errorf("pad not zeroed at %v", i)
+			}
+		}
+	}
+
+	if s := NewNumericSlice[float64](nil, 5, 32, 4); len(s) != 5 ||
+		cap(s) != 8 || uintptr(unsafe.Pointer(&s[0]))%32 != 0 {
+		t.Errorf("native fallback: len %v cap %v", len(s), cap(s))
+	}
+}
+
+func Test_TinyAllocator(t *testing.T) {
+	ac := NewTiny()
+	defer ac.Release()
+
+	d := New[PbData](ac)
+	d.Age = ac.Int(11)
+	d.Items = NewSlice[*PbItem](ac, 0, 3)
+	for i := 0; i < 3; i++ {
+		item := New[PbItem](ac)
+		item.Id = ac.Int(i)
+		item.Name = ac.String("tiny")
+		d.Items = Append(ac, d.Items, item)
+	}
+
+	if *d.Age != 11 || len(d.Items) != 3 {
+		t.Fail()
+	}
+	for i, item := range d.Items {
+		if *item.Id != i || *item.Name != "tiny" {
+			t.Errorf("item %v corrupted", i)
+		}
+	}
+}
+
+func Test_NewSliceAligned(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	// a 16-byte element, e.g. one SSE lane.
+	type vec4 struct {
+		x, y, z, w float32
+	}
+
+	for _, newSlice := range []func(l, c int) []vec4{
+		func(l, c int) []vec4 { return NewSliceAligned[vec4](ac, l, c, 16) },
+		func(l, c int) []vec4 { return NewSliceAligned[vec4](nil, l, c, 16) },
+	} {
+		s := newSlice(3, 7)
+		if len(s) != 3 || cap(s) != 7 {
+			t.Fatalf("len %v cap %v", len(s), cap(s))
+		}
+		for i := range s[:cap(s)] {
+			if uintptr(unsafe.Pointer(&s[:cap(s)][i]))%16 != 0 {
+				t.Errorf("elem %v misaligned", i)
+			}
+		}
+	}
+
+	// element sizes that are not a divisor of the alignment, and ones that
+	// are an exact multiple of it: stepping whole elements cannot align
+	// either, the raw-byte carve must.
+	type rgb struct {
+		r, g, b [8]byte
+	}
+	type lane struct {
+		v [32]byte
+	}
+	for i := 0; i < 8; i++ {
+		if s := NewSliceAligned[rgb](nil, 2, 5, 32); uintptr(unsafe.Pointer(&s[0]))%32 != 0 {
+			t.Errorf("24-byte elem misaligned")
+		}
+		if s := NewSliceAligned[lane](nil, 1, 3, 32); uintptr(unsafe.Pointer(&s[0]))%32 != 0 {
+			t.Errorf("32-byte elem misaligned")
+		}
+	}
+
+	// pointer-holding elements natively: the backing must stay scannable,
+	// so they step whole elements off a compatible base instead of raw bytes.
+	type ptrCell struct {
+		p   *int
+		pad [16]byte
+	}
+	for i := 0; i < 8; i++ {
+		s := NewSliceAligned[ptrCell](nil, 4, 4, 32)
+		if uintptr(unsafe.Pointer(&s[0]))%32 != 0 {
+			t.Errorf("pointer elem misaligned")
+		}
+		for j := range s {
+			v := new(int)
+			*v = j
+			s[j].p = v
+		}
+		runtime.GC()
+		for j := range s {
+			if *s[j].p != j {
+				t.Errorf("pointee %v collected or corrupted", j)
+			}
+		}
+	}
+
+	// small alignments take the plain NewSlice path.
+	if s := NewSliceAligned[byte](ac, 0, 8, 8); cap(s) != 8 {
+		t.Errorf("cap: %v", cap(s))
+	}
+}
+
+func Test_Weak(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	item := New[PbItem](ac)
+	item.Id = ac.Int(7)
+	w := MakeWeak(ac, item)
+
+	if p, ok := w.Get(); !ok || *p.Id != 7 {
+		t.Errorf("live handle should resolve")
+	}
+	if !w.Alive() {
+		t.Errorf("live handle should report alive")
+	}
+
+	ac.Reset()
+	if _, ok := w.Get(); ok {
+		t.Errorf("handle should die with the cycle")
+	}
+	if w.Alive() {
+		t.Errorf("dead handle should report dead")
+	}
+
+	// native objects are GC-managed, the handle stays valid.
+	n := MakeWeak(Native(), new(PbItem))
+	if _, ok := n.Get(); !ok {
+		t.Errorf("native handle should stay valid")
+	}
+
+	var zero Weak[PbItem]
+	if _, ok := zero.Get(); ok {
+		t.Errorf("zero handle should be dead")
+	}
+}
+
+func Test_Rotate(t *testing.T) {
+	ac := acPool.Get()
+
+	batches := make(chan *Allocator, 4)
+	var got []int
+
+	// producer: fill a batch, rotate, keep going without blocking.
+	for b := 0; b < 3; b++ {
+		for i := 0; i < 10; i++ {
+			item := New[PbItem](ac)
+			item.Id = ac.Int(b*10 + i)
+			got = append(got, *item.Id)
+		}
+		batches <- Rotate(&ac)
+	}
+	close(batches)
+	ac.Release()
+
+	// consumer: every handed-off arena is intact and released here.
+	seen := 0
+	for full := range batches {
+		if full.IsEmpty() {
+			t.Errorf("handed-off arena should hold the batch")
+		}
+		seen++
+		full.Release()
+	}
+	if seen != 3 || len(got) != 30 {
+		t.Errorf("batches: %v, rows: %v", seen, len(got))
+	}
+}
+
+func Test_GeometricChunks(t *testing.T) {
+	const total = 64 * 1024 * 20
+
+	fill := func(ac *Allocator) int {
+		for i := 0; i < total/1024; i++ {
+			NewSlice[byte](ac, 0, 1024)
+		}
+		return len(ac.chunks)
+	}
+
+	ac := acPool.Get()
+	fixed := fill(ac)
+	ac.Release()
+
+	ac = acPool.Get()
+	defer ac.Release()
+	ac.SetGeometricChunks(true, 1<<22)
+	geom := fill(ac)
+
+	if geom >= fixed {
+		t.Errorf("geometric growth should need fewer chunks: %v vs %v", geom, fixed)
+	}
+	for i := 1; i < len(ac.chunks); i++ {
+		if ac.chunks[i].Cap < ac.chunks[i-1].Cap {
+			t.Errorf("chunk %v shrank: %v < %v", i, ac.chunks[i].Cap, ac.chunks[i-1].Cap)
+		}
+	}
+}
+
+func Test_CastSlice(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	b := NewSlice[byte](ac, 16, 16)
+	for i := range b {
+		b[i] = byte(i)
+	}
+
+	var s []uint32
+	// first call warms the ContainsPointers cache.
+	s = CastSlice[uint32](ac, b)
+	noMalloc(func() {
+		s = CastSlice[uint32](ac, b)
+	})
+	if len(s) != 4 {
+		t.Errorf("expect 4 elems, got %v", len(s))
+	}
+	for i, v := range s {
+		if expect := *(*uint32)(unsafe.Pointer(&b[i*4])); v != expect {
+			t.Errorf("elem %v: %x != %x", i, v, expect)
+		}
+	}
+
+	// misuse: len not a multiple of the elem size.
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expect panic")
+			}
+		}()
+		CastSlice[uint32](ac, b[:3])
+	}()
+
+	// misuse: pointerful elem type.
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expect panic")
+			}
+		}()
+		CastSlice[*int](ac, b)
+	}()
+}
+
+type stringerItem struct {
+	id int
+}
+
+func (s *stringerItem) String() string {
+	return fmt.Sprint(s.id)
+}
+
+func Test_AppendInterfaceElems(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	var s []fmt.Stringer
+	for i := 0; i < 10; i++ {
+		v := New[stringerItem](ac)
+		v.id = i
+		s = Append(ac, s, fmt.Stringer(v))
+		runtime.GC()
+	}
+	for i, v := range s {
+		if v.String() != fmt.Sprint(i) {
+			t.Errorf("elem %v: %v", i, v.String())
+		}
+	}
+
+	// a heap value boxed into the interface must survive the cycle too.
+	SliceAppend(ac, &s, fmt.Stringer(&stringerItem{id: 99}))
+	runtime.GC()
+	if s[10].String() != "99" {
+		t.Errorf("heap elem gced")
+	}
+
+	// nil elems stay nil.
+	s = Append(ac, s, nil)
+	if s[11] != nil {
+		t.Errorf("nil elem")
+	}
+}
+
+func Test_TwoPhaseBuild(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	type node struct {
+		id   int
+		next *node
+	}
+
+	// phase 1: allocate all nodes; pointer fields must start nil even on
+	// reused chunk memory.
+	const n = 100
+	nodes := NewSlice[*node](ac, 0, n)
+	for i := 0; i < n; i++ {
+		nd := New[node](ac)
+		nd.id = i
+		if nd.next != nil {
+			t.Fatalf("node %v not zeroed", i)
+		}
+		nodes = Append(ac, nodes, nd)
+	}
+	// a mid-phase check must pass on the unwired graph.
+	ac.CheckExternalPointers()
+
+	// phase 2: wire a cycle.
+	for i, nd := range nodes {
+		nd.next = nodes[(i+1)%n]
+	}
+	ac.CheckExternalPointers()
+
+	for i, nd := range nodes {
+		if nd.next.id != (i+1)%n {
+			t.Errorf("node %v wired wrong", i)
+		}
+	}
+}
+
+func Test_FreeLast(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	_ = ac.alloc(16, false)
+	p := ac.alloc(64, false)
+	ac.FreeLast(p, 64)
+
+	if p2 := ac.alloc(64, false); p2 != p {
+		t.Errorf("freed space not reused: %p vs %p", p2, p)
+	}
+
+	// not the last allocation: reported.
+	q := ac.alloc(32, false)
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expect panic")
+			}
+		}()
+		ac.FreeLast(p, 64)
+	}()
+	ac.FreeLast(q, 32)
+
+	// the native allocator ignores it.
+	Native().FreeLast(p, 64)
+}
+
+func Test_NewStringFromRunes(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	runes := []rune("héllo 世界 🙂")
+	s := ac.NewStringFromRunes(runes)
+	if s != string(runes) {
+		t.Errorf("got %q", s)
+	}
+	h := (*stringHeader)(unsafe.Pointer(&s))
+	if ac.checkPointerType(uintptr(h.Data)) != pointerTypeLacInternal {
+		t.Errorf("backing not in lac")
+	}
+
+	// invalid runes encode as RuneError, matching string([]rune).
+	bad := []rune{'a', -1, 0xD800, 'z'}
+	if got := ac.NewStringFromRunes(bad); got != string(bad) {
+		t.Errorf("got %q, expect %q", got, string(bad))
+	}
+
+	if ac.NewStringFromRunes(nil) != "" {
+		t.Errorf("empty")
+	}
+	if Native().NewStringFromRunes(runes) != string(runes) {
+		t.Errorf("native")
+	}
+}
+
+func Test_ReleaseAll(t *testing.T) {
+	ac1 := acPool.Get()
+	ac2 := acPool.Get()
+	_ = New[PbItem](ac1)
+	_ = New[PbItem](ac2)
+
+	ReleaseAll(ac1, ac2, Native(), nil)
+
+	if !ac1.IsEmpty() || !ac2.IsEmpty() {
+		t.Errorf("allocators not reset")
+	}
+	ReleaseAll()
+}
+
+func Test_NewDeterministic(t *testing.T) {
+	ac := NewDeterministic()
+	defer ac.Release()
+
+	// un-zeroed memory reads back the fixed pattern, never stale data.
+	s := NewSlice[byte](ac, 64, 64)
+	for i, b := range s {
+		if b != detFillPattern {
+			t.Fatalf("byte %v: %x", i, b)
+		}
+	}
+	big := NewSlice[byte](ac, 0, 128*1024)
+	for i, b := range big[:cap(big)] {
+		if b != detFillPattern {
+			t.Fatalf("large chunk byte %v: %x", i, b)
+		}
+	}
+
+	// zeroing requests still win over the pattern.
+	item := New[PbItem](ac)
+	if item.Id != nil || item.Name != nil {
+		t.Errorf("New not zeroed")
+	}
+
+	// chunks are dropped to the GC on release, global accounting settles.
+	before := TotalReserved()
+	ac.Reset()
+	if after := TotalReserved(); after >= before {
+		t.Errorf("chunks not dropped: %v -> %v", before, after)
+	}
+}
+
+func Test_ExtendZero(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	s := NewSlice[*int](ac, 0, 2)
+	s = Append(ac, s, ac.Int(1))
+
+	ExtendZero(ac, &s, 5)
+	if len(s) != 6 {
+		t.Fatalf("len: %v", len(s))
+	}
+	if *s[0] != 1 {
+		t.Errorf("existing elem lost")
+	}
+	for i := 1; i < 6; i++ {
+		if s[i] != nil {
+			t.Errorf("elem %v not zeroed", i)
+		}
+	}
+	if ac.checkPointerType(uintptr((*sliceHeader)(unsafe.Pointer(&s)).Data)) != pointerTypeLacInternal {
+		t.Errorf("backing not in lac")
+	}
+
+	ExtendZero(ac, &s, 0)
+	if len(s) != 6 {
+		t.Errorf("n=0 changed len")
+	}
+
+	var n []int
+	ExtendZero(Native(), &n, 3)
+	if len(n) != 3 || n[0] != 0 {
+		t.Errorf("native")
+	}
+}
+
+func Test_MapSetString(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	m := NewMap[string, *PbItem](ac, 0)
+
+	// keys come from a buffer that is mutated after insertion, like a parse
+	// buffer being recycled.
+	buf := []byte("token")
+	k := string(buf)
+	item := New[PbItem](ac)
+	item.Id = ac.Int(7)
+	MapSetString(ac, m, k, item)
+
+	for i := range buf {
+		buf[i] = 'x'
+	}
+
+	got, ok := m["token"]
+	if !ok || *got.Id != 7 {
+		t.Fatalf("lookup failed after source mutated")
+	}
+	for key := range m {
+		h := (*stringHeader)(unsafe.Pointer(&key))
+		if ac.checkPointerType(uintptr(h.Data)) != pointerTypeLacInternal {
+			t.Errorf("key backing not in lac")
+		}
+	}
+
+	n := NewMap[string, *PbItem](nil, 0)
+	MapSetString(nil, n, "k", item)
+	if n["k"] != item {
+		t.Errorf("native")
+	}
+}
+
+func Test_Child(t *testing.T) {
+	parent := acPool.Get()
+	defer parent.Release()
+
+	d := New[PbData](parent)
+	d.Age = parent.Int(42)
+
+	// each subtree scratch resets independently of the parent.
+	child := parent.Child()
+	defer child.Release()
+	for i := 0; i < 100; i++ {
+		_ = New[PbItem](child)
+	}
+	child.Reset()
+
+	if !child.IsEmpty() {
+		t.Errorf("child not freed")
+	}
+	if *d.Age != 42 {
+		t.Errorf("parent data lost")
+	}
+
+	// the child keeps working after its reset.
+	item := New[PbItem](child)
+	item.Id = child.Int(1)
+	if *item.Id != 1 {
+		t.Errorf("child unusable after reset")
+	}
+
+	if Native().Child() != nil {
+		t.Errorf("native child")
+	}
+}
+
+func Test_NewAppender(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	// growth past the reserved cap goes through the arena.
+	add, done := NewAppender[int](ac, 8)
+	for i := 0; i < 100; i++ {
+		add(i)
+	}
+	s := done()
+	if len(s) != 100 {
+		t.Fatalf("len: %v", len(s))
+	}
+	for i, v := range s {
+		if v != i {
+			t.Errorf("elem %v: %v", i, v)
+		}
+	}
+	if ac.checkPointerType(uintptr((*sliceHeader)(unsafe.Pointer(&s)).Data)) != pointerTypeLacInternal {
+		t.Errorf("backing not in lac")
+	}
+
+	add2, done2 := NewAppender[int](nil, 4)
+	add2(7)
+	if n := done2(); len(n) != 1 || n[0] != 7 {
+		t.Errorf("native")
+	}
+}
+
+func Test_AssertPacked(t *testing.T) {
+	type wireHeader struct {
+		Magic   uint32
+		Version uint16
+		Flags   uint16
+		Count   uint64
+	}
+	AssertPacked(reflect.TypeOf(wireHeader{}))
+
+	type nested struct {
+		H wireHeader
+		N [2]wireHeader
+	}
+	AssertPacked(reflect.TypeOf(nested{}))
+
+	type padded struct {
+		A byte
+		B uint64
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("padded struct should panic")
+			}
+		}()
+		AssertPacked(reflect.TypeOf(padded{}))
+	}()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("non-struct should panic")
+			}
+		}()
+		AssertPacked(reflect.TypeOf(0))
+	}()
+}
+
+func Test_EnterLeave(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	var peak int64
+	var recurse func(depth int)
+	recurse = func(depth int) {
+		tok := ac.Enter()
+		defer tok.Leave()
+
+		_ = NewSlice[byte](ac, 0, 1024)
+		item := New[PbItem](ac)
+		item.Id = ac.Int(depth)
+		if used := ac.Used(); used > peak {
+			peak = used
+		}
+		if depth > 0 {
+			recurse(depth - 1)
+		}
+	}
+
+	// peak stays proportional to the depth, not the call count: sibling
+	// scopes reuse the same memory.
+	for i := 0; i < 200; i++ {
+		recurse(8)
+	}
+	if peak > 32*1024 {
+		t.Errorf("peak usage not bounded by depth: %v", peak)
+	}
+	if used := ac.Used(); used != 0 {
+		t.Errorf("scopes not rolled back: %v", used)
+	}
+
+	// Leave is idempotent; nested scopes unwind LIFO.
+	outer := ac.Enter()
+	_ = New[PbItem](ac)
+	inner := ac.Enter()
+	_ = New[PbItem](ac)
+	inner.Leave()
+	inner.Leave()
+	outer.Leave()
+	if used := ac.Used(); used != 0 {
+		t.Errorf("not unwound: %v", used)
+	}
+
+	// native scopes are no-ops.
+	tok := Native().Enter()
+	tok.Leave()
+}
+
+func Test_SetDefault(t *testing.T) {
+	if Default() != nil {
+		t.Errorf("default should start native")
+	}
+
+	ac := acPool.Get()
+	defer ac.Release()
+	SetDefault(ac)
+	defer SetDefault(nil)
+
+	if Default() != ac {
+		t.Errorf("default not set")
+	}
+	item := New[PbItem](Default())
+	item.Id = Default().Int(5)
+	if *item.Id != 5 {
+		t.Errorf("default unusable")
+	}
+
+	SetDefault(nil)
+	if Default() != nil {
+		t.Errorf("default not unset")
+	}
+	// the native fallback still allocates.
+	if New[PbItem](Default()) == nil {
+		t.Errorf("native fallback")
+	}
+}
+
+func Test_AppendForeignPointer(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	other := acPool.Get()
+	defer ac.Release()
+	defer other.Release()
+
+	var s []*PbItem
+	// own elem and Attached heap elem are fine.
+	s = Append(ac, s, New[PbItem](ac))
+	heapItem := Attach(ac, new(PbItem))
+	s = Append(ac, s, heapItem)
+	if !ac.Owns(unsafe.Pointer(s[0])) || ac.Owns(unsafe.Pointer(s[1])) {
+		t.Errorf("Owns misreports")
+	}
+
+	// an elem of another arena dangles once that arena resets.
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expect panic")
+			}
+		}()
+		s = Append(ac, s, New[PbItem](other))
+	}()
+}
+
+func Test_AdaptiveChunkSize(t *testing.T) {
+	AdaptiveChunkSize = true
+	defer func() { AdaptiveChunkSize = false }()
+
+	ac := acPool.Get()
+	defer ac.Release()
+
+	chunkSz := int64(ac.chunkPool.ChunkSize)
+
+	// a heavy cycle burning well past adaptGrowAt chunks doubles the size.
+	for i := 0; i < (adaptGrowAt+4)*int(chunkSz)/16; i++ {
+		ac.alloc(16, false)
+	}
+	ac.reset()
+	if got := atomic.LoadInt64(&ac.adaptSize); got != chunkSz*2 {
+		t.Errorf("expect grow to %v, got %v", chunkSz*2, got)
+	}
+
+	// the next cycle allocates into the doubled chunks.
+	ac.alloc(16, false)
+	if got := int64(ac.chunks[len(ac.chunks)-1].Cap); got != chunkSz*2 {
+		t.Errorf("expect a %v chunk, got %v", chunkSz*2, got)
+	}
+
+	// a quiet cycle shrinks back, floored at the pool's ChunkSize.
+	ac.reset()
+	if got := atomic.LoadInt64(&ac.adaptSize); got != chunkSz {
+		t.Errorf("expect shrink to %v, got %v", chunkSz, got)
+	}
+	ac.reset()
+	if got := atomic.LoadInt64(&ac.adaptSize); got != chunkSz {
+		t.Errorf("expect floor at %v, got %v", chunkSz, got)
+	}
+}
+
+func Test_SliceGrowthChunkRounding(t *testing.T) {
+	ac := acPool.Get()
+
+	chunkSz := ac.chunkPool.ChunkSize
+	elemSz := int(unsafe.Sizeof(int64(0)))
+
+	// grow until a step would overshoot ChunkSize: the rounded backing must
+	// fill exactly one chunk instead.
+	var s []int64
+	for i := 0; cap(s)*elemSz < chunkSz; i++ {
+		s = Append(ac, s, int64(i))
+	}
+	if cap(s)*elemSz != chunkSz {
+		t.Errorf("expect the backing to fill one chunk, cap bytes: %v", cap(s)*elemSz)
+	}
+	for i, v := range s {
+		if v != int64(i) {
+			t.Fatalf("elem %v: %v", i, v)
+		}
+	}
+
+	// every chunk of the cycle is poolable: the reset recycles, not discards.
+	miss := acPool.Stats.ChunksMiss.Load()
+	ac.Release()
+	if got := acPool.Stats.ChunksMiss.Load(); got != miss {
+		t.Errorf("expect no discarded chunks, got %v", got-miss)
+	}
+}
+
+func Test_AcquireFree(t *testing.T) {
+	ac := Acquire()
+	item := New[PbItem](ac)
+	item.Id = ac.Int(1)
+	if *item.Id != 1 {
+		t.Errorf("id")
+	}
+	ac.Release()
+
+	// many goroutines pulling and freeing concurrently, for the race detector.
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				ac := Acquire()
+				d := New[PbData](ac)
+				d.Age = ac.Int(i)
+				for j := 0; j < 4; j++ {
+					d.Items = Append(ac, d.Items, New[PbItem](ac))
+				}
+				if *d.Age != i {
+					t.Errorf("age")
+				}
+				ac.Release()
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,295 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+// Deep copy: relocate an object graph into lac memory.
+// pointers, slices, strings, maps, structs and arrays are relocated
+// recursively; shared pointees are copied once (identity-preserving).
+// interface, func and chan fields are left shared with the source,
+// keep-alive them yourself if they reference the native heap.
+
+package lac
+
+import (
+	"reflect"
+	"runtime"
+	"sync/atomic"
+	"unsafe"
+)
+
+// Clone deep-copies the object graph reachable from src into ac,
+// returning a fully lac-resident copy.
+func Clone[T any](ac *Allocator, src *T) *T {
+	if ac == nil || src == nil {
+		return src
+	}
+
+	tp := reflect.TypeOf(*src)
+	r := (*T)(ac.alloc(int(tp.Size()), true))
+	seen := map[unsafe.Pointer]unsafe.Pointer{unsafe.Pointer(src): unsafe.Pointer(r)}
+	ac.deepCopy(unsafe.Pointer(r), unsafe.Pointer(src), tp, seen)
+
+	if ac.acPool.debugMode {
+		if tp.Kind() == reflect.Struct {
+			ac.debugScan(r)
+		}
+	}
+	return r
+}
+
+func (ac *Allocator) deepCopy(dst, src unsafe.Pointer, t reflect.Type, seen map[unsafe.Pointer]unsafe.Pointer) {
+	memmoveNoHeapPointers(dst, src, t.Size())
+	ac.relocate(dst, t, seen)
+}
+
+// needRelocate reports whether a type holds anything pointing out of its own bytes.
+func needRelocate(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.String, reflect.Map:
+		return true
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if needRelocate(t.Field(i).Type) {
+				return true
+			}
+		}
+	case reflect.Array:
+		return needRelocate(t.Elem())
+	}
+	return false
+}
+
+// relocate fixes the out-of-object references of the value at p (already
+// bit-copied into lac) to point at fresh lac copies.
+func (ac *Allocator) relocate(p unsafe.Pointer, t reflect.Type, seen map[unsafe.Pointer]unsafe.Pointer) {
+	switch t.Kind() {
+	case reflect.Ptr:
+		pp := (*unsafe.Pointer)(p)
+		old := *pp
+		if old == nil {
+			return
+		}
+		if np, ok := seen[old]; ok {
+			*pp = np
+			return
+		}
+		elem := t.Elem()
+		np := ac.alloc(int(elem.Size()), true)
+		seen[old] = np
+		ac.deepCopy(np, old, elem, seen)
+		*pp = np
+
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if needRelocate(f.Type) {
+				ac.relocate(unsafe.Add(p, f.Offset), f.Type, seen)
+			}
+		}
+
+	case reflect.Array:
+		if elem := t.Elem(); needRelocate(elem) {
+			for i := 0; i < t.Len(); i++ {
+				ac.relocate(unsafe.Add(p, uintptr(i)*elem.Size()), elem, seen)
+			}
+		}
+
+	case reflect.Slice:
+		h := (*sliceHeader)(p)
+		if h.Data == nil || h.Len == 0 {
+			h.Cap = h.Len
+			return
+		}
+		elem := t.Elem()
+		esz := int(elem.Size())
+		nd := ac.alloc(int(h.Len)*esz, true)
+		memmoveNoHeapPointers(nd, h.Data, uintptr(int(h.Len)*esz))
+		h.Data = nd
+		h.Cap = h.Len
+		if needRelocate(elem) {
+			for i := 0; i < int(h.Len); i++ {
+				ac.relocate(unsafe.Add(nd, i*esz), elem, seen)
+			}
+		}
+
+	case reflect.String:
+		h := (*stringHeader)(p)
+		if h.Data == nil || h.Len == 0 {
+			return
+		}
+		nd := ac.alloc(h.Len, false)
+		memmoveNoHeapPointers(nd, h.Data, uintptr(h.Len))
+		h.Data = nd
+
+	case reflect.Map:
+		v := reflect.NewAt(t, p).Elem()
+		if v.IsNil() {
+			return
+		}
+		nm := reflect.MakeMapWithSize(t, v.Len())
+		kt, vt := t.Key(), t.Elem()
+		for iter := v.MapRange(); iter.Next(); {
+			nk := ac.cloneReflected(iter.Key(), kt, seen)
+			nv := ac.cloneReflected(iter.Value(), vt, seen)
+			nm.SetMapIndex(nk, nv)
+		}
+		v.Set(nm)
+		ac.keepAlive(nm.Interface())
+	}
+}
+
+// cloneReflected deep-copies a standalone reflect value (map key/value).
+func (ac *Allocator) cloneReflected(v reflect.Value, t reflect.Type, seen map[unsafe.Pointer]unsafe.Pointer) reflect.Value {
+	if !needRelocate(t) {
+		return v
+	}
+	tmp := reflect.New(t)
+	tmp.Elem().Set(v)
+	ac.relocate(unsafe.Pointer(tmp.Pointer()), t, seen)
+	return tmp.Elem()
+}
+
+// DeepCloneMap deep-copies src into a fresh arena map: every key and value is
+// relocated like Clone relocates a field, so pointer values (and whatever
+// they reach) end up lac-resident and the result is fully self-contained.
+// shared pointees are copied once — two keys pointing at the same object
+// point at the same arena copy afterwards. the clone dies with the cycle.
+func DeepCloneMap[K comparable, V any](ac *Allocator, src map[K]V) map[K]V {
+	if src == nil {
+		return nil
+	}
+	r := NewMap[K, V](ac, len(src))
+	if ac == nil {
+		for k, v := range src {
+			r[k] = v
+		}
+		return r
+	}
+
+	kt := reflect.TypeOf((*K)(nil)).Elem()
+	vt := reflect.TypeOf((*V)(nil)).Elem()
+	seen := map[unsafe.Pointer]unsafe.Pointer{}
+	for k, v := range src {
+		if needRelocate(kt) {
+			ac.relocate(unsafe.Pointer(&k), kt, seen)
+		}
+		if needRelocate(vt) {
+			ac.relocate(unsafe.Pointer(&v), vt, seen)
+		}
+		r[k] = v
+	}
+	return r
+}
+
+// ExtractToNewArena promotes the subtree reachable from src into an arena of
+// its own: a fresh allocator from the shared pool receives a deep copy and
+// both are returned. the copy is minimal and independent, so it can outlive
+// the request arena src was built in — the cheap way to move request-scoped
+// data into a cache, one arena per entry, released when the entry is evicted.
+func ExtractToNewArena[T any](src *T) (*Allocator, *T) {
+	ac := Acquire()
+	return ac, Clone(ac, src)
+}
+
+// ResetExcept resets the allocator like reset but carries exactly one object
+// across the cycle: the carry graph is deep-copied into fresh chunks first,
+// then the old cycle is torn down with the same bookkeeping as reset.
+// returns the relocated carry object; the old pointer is invalid afterwards.
+func ResetExcept[T any](ac *Allocator, carry *T) *T {
+	if ac == nil {
+		return carry
+	}
+
+	// tracking of the dead cycle is dropped, the relocated carry is rescanned by Clone.
+	if ac.acPool.debugMode {
+		ac.dbgScanObjs.Clear()
+		ac.dbgScanSkipped = 0
+	}
+
+	oldChunks := ac.chunks
+	ac.chunks = make([]*sliceHeader, 0, 4)
+	ac.curChunk = nil
+	ac.regions = ac.regions[:0]
+	ac.root = nil
+	ac.rootType = nil
+
+	// snapshot the dead cycle's keep-alives: they must stay reachable until
+	// the carry is copied out below, then die with the cycle — while the
+	// registrations Clone adds for the copy start on cleared queues.
+	oldPtrs := ac.externalPtr.slice
+	oldSlices := ac.externalSlice.slice
+	oldStrings := ac.externalString.slice
+	oldMaps := ac.externalMap.slice
+	oldFuncs := ac.externalFunc.slice
+	ac.externalPtr.Clear()
+	ac.externalSlice.Clear()
+	ac.externalString.Clear()
+	ac.externalMap.Clear()
+	ac.externalFunc.Clear()
+
+	r := Clone(ac, carry)
+
+	// from here on, mirror reset()'s teardown of the finished cycle.
+	if ac.acPool.debugMode && DbgResetCanaries {
+		ac.verifyCanaries()
+		ac.canaried = append(ac.canaried[:0], oldChunks...)
+		ac.stampCanaries()
+	} else {
+		ac.canaried = nil
+	}
+
+	if AdaptiveChunkSize {
+		ac.adaptChunkSize(len(oldChunks))
+	}
+
+	if ac.acPool.debugMode {
+		gen := ac.generation.Load()
+		for _, c := range oldChunks {
+			ac.staleRanges = append(ac.staleRanges,
+				genRange{uintptr(c.Data), uintptr(c.Data) + uintptr(c.Cap), gen})
+		}
+	} else {
+		ac.staleRanges = nil
+	}
+
+	ac.releaseChunks(oldChunks)
+
+	// the free lists hold raw pointers into the released chunks; keeping
+	// them would hand recycled memory to the next AllocRecyclable.
+	for i := range ac.freeLists {
+		ac.freeLists[i] = ac.freeLists[i][:0]
+	}
+
+	for _, m := range ac.guardMappings {
+		guardFree(m)
+	}
+	ac.guardMappings = nil
+
+	// the overflow tier is torn down with its facade.
+	if ov := ac.overflow; ov != nil {
+		ac.overflow = nil
+		ov.Release()
+	}
+	atomic.StoreInt64(&ac.overflowBudget, 0)
+	atomic.StoreInt64(&ac.primaryUsed, 0)
+
+	// a Branch child unfreezes its parent here.
+	if t := ac.parentToken; t != nil {
+		ac.parentToken = nil
+		t.Return()
+	}
+
+	// invalidate all Weak handles of the finished cycle: its chunks are
+	// recycled just like on a plain reset.
+	ac.generation.Add(1)
+
+	runtime.KeepAlive([]any{oldPtrs, oldSlices, oldStrings, oldMaps, oldFuncs})
+
+	ac.refCnt.Store(1)
+	return r
+}
@@ -0,0 +1,222 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+package lac
+
+import (
+	"fmt"
+	"testing"
+	"unsafe"
+)
+
+type cloneCtx struct {
+	Id    *int
+	Name  string
+	Items []*PbItem
+	Tags  map[string]*int
+	Self  *cloneCtx
+}
+
+func makeCloneCtx(ac *Allocator) *cloneCtx {
+	c := New[cloneCtx](ac)
+	c.Id = ac.Int(7)
+	c.Name = ac.NewString("ctx")
+	c.Items = NewSlice[*PbItem](ac, 0, 2)
+	for i := 0; i < 2; i++ {
+		item := New[PbItem](ac)
+		item.Id = ac.Int(i)
+		item.Name = ac.String("item")
+		c.Items = Append(ac, c.Items, item)
+	}
+	c.Tags = NewMap[string, *int](ac, 0)
+	c.Tags[ac.NewString("k")] = ac.Int(42)
+	c.Self = c // cycle
+	return c
+}
+
+func checkCloneCtx(t *testing.T, ac *Allocator, c *cloneCtx) {
+	t.Helper()
+	if *c.Id != 7 || c.Name != "ctx" {
+		t.Errorf("scalars: %+v", c)
+	}
+	if len(c.Items) != 2 {
+		t.Fatalf("items: %v", len(c.Items))
+	}
+	for i, item := range c.Items {
+		if *item.Id != i || *item.Name != "item" {
+			t.Errorf("item %v", i)
+		}
+		if ac.checkPointerType(uintptr(unsafe.Pointer(item))) != pointerTypeLacInternal {
+			t.Errorf("item %v not relocated", i)
+		}
+	}
+	if *c.Tags["k"] != 42 {
+		t.Errorf("map")
+	}
+	if c.Self != c {
+		t.Errorf("cycle not preserved")
+	}
+}
+
+func Test_Clone(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	src := makeCloneCtx(nil) // native heap graph
+	c := Clone(ac, src)
+	if c == src {
+		t.Fatal("not copied")
+	}
+	checkCloneCtx(t, ac, c)
+
+	// shared pointees keep their sharing in the copy.
+	type pair struct{ A, B *int }
+	p := &pair{}
+	p.A = new(int)
+	p.B = p.A
+	cp := Clone(ac, p)
+	if cp.A != cp.B {
+		t.Errorf("sharing lost")
+	}
+}
+
+func Test_ResetExcept(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	c := makeCloneCtx(ac)
+	// garbage that must die with the reset.
+	for i := 0; i < 10000; i++ {
+		_ = New[PbData](ac)
+	}
+	before := len(ac.chunks)
+
+	c = ResetExcept(ac, c)
+	checkCloneCtx(t, ac, c)
+
+	if len(ac.chunks) >= before {
+		t.Errorf("old chunks not recycled: %v -> %v", before, len(ac.chunks))
+	}
+}
+
+func Test_DeepCloneMap(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	shared := &PbItem{Id: new(int)}
+	*shared.Id = 7
+	src := map[string]*PbItem{
+		"a": shared,
+		"b": shared,
+		"c": {Id: new(int)},
+	}
+	*src["c"].Id = 9
+
+	m := DeepCloneMap(ac, src)
+	if len(m) != 3 {
+		t.Fatalf("len: %v", len(m))
+	}
+	if *m["a"].Id != 7 || *m["c"].Id != 9 {
+		t.Errorf("values")
+	}
+	for k, v := range m {
+		if v == src[k] {
+			t.Errorf("value %v not relocated", k)
+		}
+		if !ac.Owns(unsafe.Pointer(v)) {
+			t.Errorf("value %v not lac-resident", k)
+		}
+	}
+	// shared pointees stay shared in the clone.
+	if m["a"] != m["b"] {
+		t.Errorf("sharing not preserved")
+	}
+	// and the clone is detached from the source.
+	*m["a"].Id = 100
+	if *shared.Id != 7 {
+		t.Errorf("clone aliases the source")
+	}
+
+	// native fallback: a plain copy.
+	n := DeepCloneMap(nil, src)
+	if len(n) != 3 || n["a"] != shared {
+		t.Errorf("native path")
+	}
+}
+
+func Test_ExtractToNewArena(t *testing.T) {
+	req := acPool.Get()
+
+	// a request-scoped graph; only the second item gets promoted.
+	d := New[PbData](req)
+	for i := 0; i < 3; i++ {
+		item := New[PbItem](req)
+		item.Id = req.Int(i)
+		item.Name = req.String(fmt.Sprintf("item%v", i))
+		d.Items = Append(req, d.Items, item)
+	}
+
+	cacheAc, cached := ExtractToNewArena(d.Items[1])
+	defer cacheAc.Release()
+
+	if cached == d.Items[1] {
+		t.Errorf("not copied")
+	}
+	if !cacheAc.Owns(unsafe.Pointer(cached)) {
+		t.Errorf("copy not owned by the new arena")
+	}
+
+	// the copy survives the request arena.
+	req.Release()
+	if *cached.Id != 1 || *cached.Name != "item1" {
+		t.Errorf("cached subtree corrupted")
+	}
+}
+
+func Test_ResetExceptFreeList(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	// a recycled buffer from the old cycle must not survive the reset.
+	ac.Free(ac.AllocRecyclable(64))
+
+	carry := New[PbItem](ac)
+	carry.Id = ac.Int(5)
+	carry = ResetExcept(ac, carry)
+	if *carry.Id != 5 {
+		t.Errorf("carry corrupted")
+	}
+
+	// the next recyclable buffer comes from the new cycle's chunks, not
+	// from a free list pointing into the released ones.
+	b := ac.AllocRecyclable(64)
+	if !ac.Owns(unsafe.Pointer(&b[0])) {
+		t.Errorf("buffer backed by released memory")
+	}
+}
+
+func Test_ResetExceptWeak(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	item := New[PbItem](ac)
+	w := MakeWeak(ac, item)
+	if !w.Alive() {
+		t.Fatalf("fresh handle dead")
+	}
+
+	// the old cycle's chunks are recycled, so the handle must go stale
+	// exactly like after a plain reset.
+	carry := New[PbItem](ac)
+	_ = ResetExcept(ac, carry)
+	if _, ok := w.Get(); ok {
+		t.Errorf("Weak survived ResetExcept")
+	}
+}
@@ -0,0 +1,74 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+// Compatibility shim for call sites written against the legacy linear_ac API.
+// the legacy names map to lac as follows:
+//
+//	New2(ac, src)             -> NewFrom(ac, src)
+//	SliceAppend(ac, &s, v...) -> s = Append(ac, s, v...)
+//	ac.DbgCheckPointers()     -> ac.CheckExternalPointers()
+//	ac.Reset()                -> get a fresh allocator from the pool
+//
+// these thin wrappers let old code compile against lac with minimal edits;
+// new code should use the lac names directly.
+//
+// NOTE: the legacy package itself is not part of this repository, so the
+// generic fast paths cannot be backported there; this shim is the supported
+// bridge instead. point the old import path at lac and the legacy names keep
+// compiling, while New/NewSlice/Append are the generic layer already.
+
+package lac
+
+import (
+	"reflect"
+)
+
+// New2 is the legacy name of NewFrom.
+func New2[T any](ac *Allocator, src *T) *T {
+	return NewFrom(ac, src)
+}
+
+// SliceAppend is the legacy appender, mutating the slice in place.
+func SliceAppend[T any](ac *Allocator, s *[]T, elems ...T) {
+	*s = Append(ac, *s, elems...)
+}
+
+// DbgCheckPointers is the legacy name of CheckExternalPointers.
+func (ac *Allocator) DbgCheckPointers() {
+	if ac == nil {
+		return
+	}
+	ac.CheckExternalPointers()
+}
+
+// Enum is the legacy reflection-based enum allocator, kept for call sites
+// predating generics: it boxes the value and returns interface{}, forcing a
+// type assertion at the caller (ac.Enum(EnumVal2).(*EnumA)).
+// prefer NewEnum, which is reflection-free and returns *T directly.
+func (ac *Allocator) Enum(e interface{}) interface{} {
+	v := reflect.ValueOf(e)
+	if ac == nil {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		return p.Interface()
+	}
+	p := reflect.NewAt(v.Type(), ac.alloc(int(v.Type().Size()), false))
+	p.Elem().Set(v)
+	return p.Interface()
+}
+
+// Reset recycles all allocations at once but keeps the allocator usable,
+// matching the legacy reuse-in-place lifecycle.
+// prefer Release + AllocatorPool.Get for the pooled lifecycle.
+func (ac *Allocator) Reset() {
+	if ac == nil {
+		return
+	}
+	ac.reset()
+}
@@ -0,0 +1,65 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+package lac
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// legacy-style test body compiled against the shim.
+func Test_CompatShim(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	for round := 0; round < 3; round++ {
+		d := New2(ac, &PbData{Age: ac.Int(11)})
+		if *d.Age != 11 {
+			t.Fail()
+		}
+
+		for i := 0; i < 3; i++ {
+			item := New2(ac, &PbItem{
+				Id:   ac.Int(i),
+				Name: ac.String("name"),
+			})
+			SliceAppend(ac, &d.Items, item)
+		}
+		if len(d.Items) != 3 {
+			t.Fail()
+		}
+		for i, item := range d.Items {
+			if *item.Id != i || *item.Name != "name" {
+				t.Fail()
+			}
+		}
+
+		ac.DbgCheckPointers()
+		ac.Reset()
+	}
+}
+
+func Test_LegacyEnum(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	v := ac.Enum(EnumVal2).(*EnumA)
+	if *v != EnumVal2 {
+		t.Errorf("value")
+	}
+	if ac.checkPointerType(uintptr(unsafe.Pointer(v))) != pointerTypeLacInternal {
+		t.Errorf("not in lac")
+	}
+
+	n := Native().Enum(EnumVal1).(*EnumA)
+	if *n != EnumVal1 {
+		t.Errorf("native")
+	}
+}
@@ -0,0 +1,499 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+// Small lac-backed data structures.
+// they combine the allocation APIs into structures frequently needed by the
+// parser/request-processing workloads lac targets, keeping both the headers
+// and the elements in lac memory.
+
+package lac
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// StableSlice stores the elements behind lac pointers, so the address returned
+// by Append stays valid when the slice grows, unlike &s[i] of a plain slice
+// which dangles after the backing is relocated.
+// useful for slices of large elements whose addresses are held across appends.
+type StableSlice[T any] struct {
+	ac    *Allocator
+	elems []*T
+}
+
+func NewStableSlice[T any](ac *Allocator) *StableSlice[T] {
+	s := New[StableSlice[T]](ac)
+	s.ac = ac
+	return s
+}
+
+// Append stores v into lac and returns its stable pointer.
+func (s *StableSlice[T]) Append(v T) *T {
+	p := New[T](s.ac)
+	*p = v
+	s.elems = Append(s.ac, s.elems, p)
+	return p
+}
+
+func (s *StableSlice[T]) Len() int {
+	return len(s.elems)
+}
+
+func (s *StableSlice[T]) At(i int) *T {
+	return s.elems[i]
+}
+
+// StripedSlice spreads its elements across fixed-size stripes instead of one
+// contiguous backing, so a very large dataset never demands a single huge
+// dedicated chunk (see MaxChunkSize) and growth never copies elements.
+// indexing pays one division; elements are not contiguous across stripes.
+type StripedSlice[T any] struct {
+	ac      *Allocator
+	stripes [][]T
+	stripe  int // elements per stripe
+	len     int
+}
+
+func NewStripedSlice[T any](ac *Allocator) *StripedSlice[T] {
+	s := New[StripedSlice[T]](ac)
+	s.ac = ac
+
+	var t T
+	elemSz := int(unsafe.Sizeof(t))
+	if elemSz == 0 {
+		elemSz = 1
+	}
+	chunkSz := 64 * 1024
+	if ac != nil {
+		chunkSz = ac.chunkPool.ChunkSize
+	}
+	s.stripe = max(1, chunkSz/elemSz)
+	return s
+}
+
+// Append stores v and returns its index. existing elements never move.
+func (s *StripedSlice[T]) Append(v T) int {
+	if s.len == len(s.stripes)*s.stripe {
+		s.stripes = Append(s.ac, s.stripes, NewSlice[T](s.ac, 0, s.stripe))
+	}
+	i := s.len / s.stripe
+	n := len(s.stripes[i])
+	s.stripes[i] = s.stripes[i][:n+1]
+	s.stripes[i][n] = v
+	s.len++
+	return s.len - 1
+}
+
+func (s *StripedSlice[T]) Len() int {
+	return s.len
+}
+
+// At returns the address of element i, stable across appends.
+func (s *StripedSlice[T]) At(i int) *T {
+	return &s.stripes[i/s.stripe][i%s.stripe]
+}
+
+// Cache is a read-through memoization table whose values live in lac.
+// the index map is tracked like any NewMap and the values are arena
+// objects, so the whole cache is invalidated together on the allocator
+// reset — no stale entries can leak into the next cycle.
+type Cache[K comparable, V any] struct {
+	ac *Allocator
+	m  map[K]*V
+}
+
+func NewCache[K comparable, V any](ac *Allocator) *Cache[K, V] {
+	c := New[Cache[K, V]](ac)
+	c.ac = ac
+	c.m = NewMap[K, *V](ac, 0)
+	return c
+}
+
+// GetOrCompute returns the value cached under k, computing and
+// arena-storing it on the first miss.
+func (c *Cache[K, V]) GetOrCompute(k K, compute func() V) *V {
+	if v, ok := c.m[k]; ok {
+		return v
+	}
+	p := New[V](c.ac)
+	*p = compute()
+	c.m[k] = p
+	return p
+}
+
+func (c *Cache[K, V]) Len() int {
+	return len(c.m)
+}
+
+// TypedPool is a per-allocator free list for one struct type: objects Put
+// back within a cycle are handed out again by Get instead of growing the
+// arena, cutting the peak of workloads churning a single type.
+// everything dies with the allocator cycle, Put is optional.
+type TypedPool[T any] struct {
+	lock spinLock
+	ac   *Allocator
+	free []*T
+	zero bool
+}
+
+func NewTypedPool[T any](ac *Allocator) *TypedPool[T] {
+	p := New[TypedPool[T]](ac)
+	p.ac = ac
+	var t T
+	// a recycled object may hold stale pointers: zero it on Get so the
+	// write barrier never sees garbage.
+	p.zero = ContainsPointers(reflect.TypeOf(t))
+	return p
+}
+
+// Get returns an object from the free list when possible.
+// recycled objects of pointer-holding types are zeroed, so the stale
+// pointers never reach the write barrier; plain data is kept as-is like
+// NewSlice does, zero it yourself for your need.
+func (p *TypedPool[T]) Get() *T {
+	p.lock.Lock()
+	if n := len(p.free); n > 0 {
+		r := p.free[n-1]
+		p.free = p.free[:n-1]
+		p.lock.Unlock()
+		if p.zero {
+			memclrNoHeapPointers(unsafe.Pointer(r), unsafe.Sizeof(*r))
+		}
+		return r
+	}
+	p.lock.Unlock()
+	return New[T](p.ac)
+}
+
+// Put hands obj back for reuse within the current cycle.
+func (p *TypedPool[T]) Put(obj *T) {
+	if obj == nil {
+		return
+	}
+	p.lock.Lock()
+	p.free = Append(p.ac, p.free, obj)
+	p.lock.Unlock()
+}
+
+// Variant is a tagged union for variant-heavy data models such as ASTs:
+// a user-defined tag plus an arena-allocated payload.
+// Go has no native sum types, so the tag discipline is yours; read the
+// payload through VariantAs to get the tag checked.
+type Variant struct {
+	Tag  int
+	data unsafe.Pointer
+}
+
+// NewVariant allocates a variant holding v under the given tag.
+// the payload lives in the arena and dies with the cycle.
+func NewVariant[T any](ac *Allocator, tag int, v T) *Variant {
+	r := New[Variant](ac)
+	r.Tag = tag
+	p := New[T](ac)
+	*p = v
+	r.data = unsafe.Pointer(p)
+	return r
+}
+
+// VariantAs returns the payload as *T if the variant holds the given tag,
+// nil otherwise. define your AsInt/AsStr accessors as thin wrappers over it.
+func VariantAs[T any](v *Variant, tag int) *T {
+	if v == nil || v.Tag != tag {
+		return nil
+	}
+	return (*T)(v.data)
+}
+
+// Is reports whether the variant holds the given tag.
+func (v *Variant) Is(tag int) bool {
+	return v != nil && v.Tag == tag
+}
+
+// Trie is an arena-backed byte trie for routing and prefix lookups: the
+// nodes and their child lists all come from the arena, so a table of many
+// small nodes costs the GC nothing and dies with the cycle.
+// children sit in a small linear-scanned slice, the fan-out of path-style
+// keys rarely justifies a map.
+type Trie[V any] struct {
+	ac   *Allocator
+	root *trieNode[V]
+}
+
+type trieNode[V any] struct {
+	children []trieChild[V]
+	val      V
+	hasVal   bool
+}
+
+type trieChild[V any] struct {
+	b byte
+	n *trieNode[V]
+}
+
+// NewTrie allocates an empty trie from the arena.
+func NewTrie[V any](ac *Allocator) *Trie[V] {
+	t := New[Trie[V]](ac)
+	t.ac = ac
+	t.root = New[trieNode[V]](ac)
+	return t
+}
+
+func (n *trieNode[V]) child(ac *Allocator, b byte, insert bool) *trieNode[V] {
+	for _, c := range n.children {
+		if c.b == b {
+			return c.n
+		}
+	}
+	if !insert {
+		return nil
+	}
+	c := trieChild[V]{b: b, n: New[trieNode[V]](ac)}
+	n.children = Append(ac, n.children, c)
+	return c.n
+}
+
+// Insert stores v under key, overwriting a previous value.
+func (t *Trie[V]) Insert(key string, v V) {
+	n := t.root
+	for i := 0; i < len(key); i++ {
+		n = n.child(t.ac, key[i], true)
+	}
+	n.val = v
+	n.hasVal = true
+}
+
+// Lookup returns the value stored under exactly key.
+func (t *Trie[V]) Lookup(key string) (v V, ok bool) {
+	n := t.root
+	for i := 0; i < len(key); i++ {
+		if n = n.child(t.ac, key[i], false); n == nil {
+			return
+		}
+	}
+	return n.val, n.hasVal
+}
+
+// LongestPrefix returns the value of the longest inserted key that prefixes
+// key, with the prefix length, e.g. route "/api" matching "/api/users".
+func (t *Trie[V]) LongestPrefix(key string) (v V, n int, ok bool) {
+	node := t.root
+	if node.hasVal {
+		v, ok = node.val, true
+	}
+	for i := 0; i < len(key); i++ {
+		if node = node.child(t.ac, key[i], false); node == nil {
+			return
+		}
+		if node.hasVal {
+			v, n, ok = node.val, i+1, true
+		}
+	}
+	return
+}
+
+// ColumnBuilder builds several arena slices in one interleaved loop, the
+// columnar pattern: row by row, each column appended in turn. appending to
+// the columns directly makes their growth interleave and fragment across
+// chunks; the builder reserves one contiguous region per column up front,
+// sized by the shared row hint, so each column's backing stays a single
+// block. a column past the hint relocates wholesale and stays contiguous.
+type ColumnBuilder struct {
+	ac   *Allocator
+	rows int
+}
+
+// NewColumnBuilder makes a builder whose columns each reserve room for
+// rowsHint elements up front.
+func NewColumnBuilder(ac *Allocator, rowsHint int) *ColumnBuilder {
+	b := New[ColumnBuilder](ac)
+	b.ac = ac
+	if rowsHint < 1 {
+		rowsHint = 1
+	}
+	b.rows = rowsHint
+	return b
+}
+
+// Column is one typed column under construction.
+type Column[T any] struct {
+	ac *Allocator
+	s  []T
+}
+
+// AddColumn reserves a new column on the builder.
+func AddColumn[T any](b *ColumnBuilder) *Column[T] {
+	c := New[Column[T]](b.ac)
+	c.ac = b.ac
+	c.s = NewSlice[T](b.ac, 0, b.rows)
+	return c
+}
+
+// Append adds one row's value to the column.
+func (c *Column[T]) Append(v T) {
+	c.s = Append(c.ac, c.s, v)
+}
+
+// Rows returns the built column as a plain arena slice.
+func (c *Column[T]) Rows() []T {
+	return c.s
+}
+
+// Queue is a FIFO backed by lac-allocated blocks of elements: a deque of
+// blocks, so Push and Pop are O(1) with no per-item allocation and growth is
+// unbounded — a new block comes from the arena when the tail fills, unlike a
+// ring buffer. blocks drained at the head are recycled for later pushes
+// within the cycle.
+type Queue[T any] struct {
+	ac         *Allocator
+	head, tail *queueBlock[T]
+	headIdx    int // next slot to pop in head
+	tailIdx    int // next slot to fill in tail
+	free       *queueBlock[T]
+	n          int
+	track      bool // keep pointer elements alive, see Push
+}
+
+const queueBlockCap = 64
+
+type queueBlock[T any] struct {
+	elems [queueBlockCap]T
+	next  *queueBlock[T]
+}
+
+func NewQueue[T any](ac *Allocator) *Queue[T] {
+	q := New[Queue[T]](ac)
+	q.ac = ac
+	switch reflect.TypeOf((*T)(nil)).Elem().Kind() {
+	case reflect.Ptr, reflect.Interface:
+		q.track = ac != nil
+	}
+	return q
+}
+
+func (q *Queue[T]) Len() int {
+	return q.n
+}
+
+// Push appends v at the tail. pointer and interface elements are registered
+// for keep-alive like Append does: the blocks live in arena memory, so a
+// heap pointee stored there would otherwise be invisible to the GC.
+func (q *Queue[T]) Push(v T) {
+	if q.tail == nil || q.tailIdx == queueBlockCap {
+		var b *queueBlock[T]
+		if q.free != nil {
+			b, q.free = q.free, q.free.next
+			b.next = nil
+		} else {
+			b = New[queueBlock[T]](q.ac)
+		}
+		if q.tail == nil {
+			q.head, q.tail = b, b
+		} else {
+			q.tail.next = b
+			q.tail = b
+		}
+		q.tailIdx = 0
+	}
+	if q.track {
+		if d := data(v); d != nil {
+			q.ac.externalPtr.Put(d)
+		}
+	}
+	q.tail.elems[q.tailIdx] = v
+	q.tailIdx++
+	q.n++
+}
+
+// Pop removes and returns the head element, ok=false on an empty queue.
+func (q *Queue[T]) Pop() (v T, ok bool) {
+	if q.n == 0 {
+		return v, false
+	}
+	b := q.head
+	v = b.elems[q.headIdx]
+
+	var zero T
+	b.elems[q.headIdx] = zero
+	q.headIdx++
+	q.n--
+
+	if q.headIdx == queueBlockCap {
+		// drained: recycle the block for later pushes.
+		q.head = b.next
+		b.next, q.free = q.free, b
+		if q.head == nil {
+			q.tail = nil
+			q.tailIdx = 0
+		}
+		q.headIdx = 0
+	}
+	return v, true
+}
+
+// Stack is a LIFO over one arena slice, the explicit worklist for iterative
+// (recursion-free) traversals. size it with the expected depth: pushing past
+// the capacity grows the slice via Append, which may leave the old backing
+// array dead in the arena for the rest of the cycle.
+type Stack[T any] struct {
+	ac    *Allocator
+	s     []T
+	track bool // keep pointer elements alive, see Push
+}
+
+func NewStack[T any](ac *Allocator, cap int) *Stack[T] {
+	st := New[Stack[T]](ac)
+	st.ac = ac
+	st.s = NewSlice[T](ac, 0, cap)
+	switch reflect.TypeOf((*T)(nil)).Elem().Kind() {
+	case reflect.Ptr, reflect.Interface:
+		st.track = ac != nil
+	}
+	return st
+}
+
+func (st *Stack[T]) Len() int {
+	return len(st.s)
+}
+
+// Push appends v on top. pointer and interface elements are registered for
+// keep-alive like Append does.
+func (st *Stack[T]) Push(v T) {
+	if st.track {
+		if d := data(v); d != nil {
+			st.ac.externalPtr.Put(d)
+		}
+	}
+	if len(st.s) < cap(st.s) {
+		st.s = st.s[:len(st.s)+1]
+		st.s[len(st.s)-1] = v
+	} else {
+		st.s = Append(st.ac, st.s, v)
+	}
+}
+
+// Pop removes and returns the top element, ok=false on an empty stack.
+func (st *Stack[T]) Pop() (v T, ok bool) {
+	if len(st.s) == 0 {
+		return v, false
+	}
+	v = st.s[len(st.s)-1]
+	var zero T
+	st.s[len(st.s)-1] = zero
+	st.s = st.s[:len(st.s)-1]
+	return v, true
+}
+
+// Peek returns the top element without removing it.
+func (st *Stack[T]) Peek() (v T, ok bool) {
+	if len(st.s) == 0 {
+		return v, false
+	}
+	return st.s[len(st.s)-1], true
+}
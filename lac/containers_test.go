@@ -0,0 +1,407 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+package lac
+
+import (
+	"runtime"
+	"unsafe"
+
+	"testing"
+)
+
+func Test_StripedSlice(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	type Big struct {
+		v [512]int // 4KB, 16 per 64KB stripe
+	}
+
+	s := NewStripedSlice[Big](ac)
+	first := s.At(s.Append(Big{v: [512]int{42}}))
+
+	const n = 100 // spans several stripes
+	for i := 0; i < n; i++ {
+		s.Append(Big{v: [512]int{i}})
+	}
+
+	if s.Len() != n+1 {
+		t.Fatalf("len: %v", s.Len())
+	}
+	if len(s.stripes) < 2 {
+		t.Errorf("expected several stripes: %v", len(s.stripes))
+	}
+	// none of the stripes needed a dedicated large chunk.
+	for i, st := range s.stripes {
+		if cap(st)*int(unsafe.Sizeof(Big{})) > ac.chunkPool.ChunkSize {
+			t.Errorf("stripe %v oversized", i)
+		}
+	}
+
+	// addresses are stable across growth.
+	if first != s.At(0) || first.v[0] != 42 {
+		t.Errorf("element moved or corrupted")
+	}
+	for i := 0; i < n; i++ {
+		if s.At(i + 1).v[0] != i {
+			t.Errorf("elem %v", i)
+		}
+	}
+}
+
+func Test_MaxChunkSizeGuard(t *testing.T) {
+	acPool.EnableDebugMode(false)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	MaxChunkSize = 256 * 1024
+	defer func() { MaxChunkSize = 0 }()
+
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("oversized chunk not reported")
+		}
+	}()
+	_ = NewSlice[byte](ac, 0, MaxChunkSize*2)
+}
+
+func Test_Cache(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	computed := 0
+	c := NewCache[string, PbItem](ac)
+
+	a := c.GetOrCompute("a", func() PbItem {
+		computed++
+		return PbItem{Id: ac.Int(1)}
+	})
+	b := c.GetOrCompute("a", func() PbItem {
+		computed++
+		return PbItem{Id: ac.Int(2)}
+	})
+
+	if computed != 1 {
+		t.Errorf("computed %v times", computed)
+	}
+	if a != b || *a.Id != 1 {
+		t.Errorf("cache miss on hit: %+v", a)
+	}
+	if c.Len() != 1 {
+		t.Errorf("len: %v", c.Len())
+	}
+
+	// values are arena-resident, invalidated with the cycle.
+	if ac.checkPointerType(uintptr(unsafe.Pointer(a))) != pointerTypeLacInternal {
+		t.Errorf("value not in lac")
+	}
+}
+
+func Test_TypedPool(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	p := NewTypedPool[PbItem](ac)
+
+	a := p.Get()
+	a.Id = ac.Int(1)
+	a.Name = ac.String("a")
+	p.Put(a)
+
+	// recycled, and zeroed because PbItem holds pointers.
+	b := p.Get()
+	if b != a {
+		t.Errorf("not reused")
+	}
+	if *b != (PbItem{}) {
+		t.Errorf("stale pointers survived: %+v", b)
+	}
+
+	// free list drained: fresh allocation.
+	c := p.Get()
+	if c == b {
+		t.Errorf("double get")
+	}
+
+	// objects stay usable with the debug checker active.
+	b.Id = ac.Int(2)
+	c.Id = ac.Int(3)
+	if *b.Id != 2 || *c.Id != 3 {
+		t.Fail()
+	}
+}
+
+func Test_TypedPoolPlainData(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	type pod struct{ v [4]int }
+	p := NewTypedPool[pod](ac)
+	a := p.Get()
+	a.v[0] = 42
+	p.Put(a)
+
+	// pointer-free types are handed back as-is.
+	if b := p.Get(); b != a || b.v[0] != 42 {
+		t.Errorf("pod reuse: %+v", b)
+	}
+}
+
+func Test_StableSlice(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	type Big struct {
+		v [16]int
+	}
+
+	s := NewStableSlice[Big](ac)
+	first := s.Append(Big{v: [16]int{1, 2, 3}})
+
+	for i := 0; i < 1000; i++ {
+		s.Append(Big{v: [16]int{i}})
+	}
+
+	// the held pointer must survive all the growth.
+	if first != s.At(0) {
+		t.Errorf("element moved")
+	}
+	if first.v[0] != 1 || first.v[1] != 2 || first.v[2] != 3 {
+		t.Errorf("element corrupted")
+	}
+	if s.Len() != 1001 {
+		t.Errorf("len: %v", s.Len())
+	}
+	for i := 0; i < 1000; i++ {
+		if s.At(i + 1).v[0] != i {
+			t.Errorf("elem %v", i)
+		}
+	}
+}
+
+func Test_Variant(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	// a tiny expression tree: (1 + 2) + 3.
+	const (
+		tagNum = iota
+		tagAdd
+	)
+	type addExpr struct {
+		lhs, rhs *Variant
+	}
+
+	var eval func(v *Variant) int
+	eval = func(v *Variant) int {
+		if n := VariantAs[int](v, tagNum); n != nil {
+			return *n
+		}
+		add := VariantAs[addExpr](v, tagAdd)
+		return eval(add.lhs) + eval(add.rhs)
+	}
+
+	num := func(n int) *Variant { return NewVariant(ac, tagNum, n) }
+	add := func(l, r *Variant) *Variant {
+		return NewVariant(ac, tagAdd, addExpr{lhs: l, rhs: r})
+	}
+
+	root := add(add(num(1), num(2)), num(3))
+	if got := eval(root); got != 6 {
+		t.Errorf("eval: %v", got)
+	}
+	if !root.Is(tagAdd) || root.Is(tagNum) {
+		t.Errorf("tag check failed")
+	}
+	if VariantAs[int](root, tagNum) != nil {
+		t.Errorf("mismatched tag should yield nil")
+	}
+}
+
+func Test_Trie(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	defer acPool.EnableDebugMode(false)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	tr := NewTrie[int](ac)
+	routes := map[string]int{
+		"/":          0,
+		"/api":       1,
+		"/api/users": 2,
+		"/static":    3,
+	}
+	for k, v := range routes {
+		tr.Insert(k, v)
+	}
+
+	for k, v := range routes {
+		if got, ok := tr.Lookup(k); !ok || got != v {
+			t.Errorf("lookup %q: %v %v", k, got, ok)
+		}
+	}
+	if _, ok := tr.Lookup("/ap"); ok {
+		t.Errorf("partial key should miss")
+	}
+
+	if v, n, ok := tr.LongestPrefix("/api/orders"); !ok || v != 1 || n != 4 {
+		t.Errorf("longest prefix: %v %v %v", v, n, ok)
+	}
+	if v, _, ok := tr.LongestPrefix("/api/users/7"); !ok || v != 2 {
+		t.Errorf("longest prefix: %v %v", v, ok)
+	}
+
+	ac.CheckExternalPointers()
+}
+
+func Test_ColumnBuilder(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	const rows = 100
+	b := NewColumnBuilder(ac, rows)
+	ids := AddColumn[int](b)
+	scores := AddColumn[float64](b)
+	names := AddColumn[string](b)
+
+	for i := 0; i < rows; i++ {
+		ids.Append(i)
+		scores.Append(float64(i) / 2)
+		names.Append(ac.NewString("n"))
+	}
+
+	if len(ids.Rows()) != rows || len(scores.Rows()) != rows || len(names.Rows()) != rows {
+		t.Fatalf("row counts: %v %v %v", len(ids.Rows()), len(scores.Rows()), len(names.Rows()))
+	}
+
+	s := ids.Rows()
+	for i := range s {
+		if s[i] != i {
+			t.Errorf("ids[%v] = %v", i, s[i])
+		}
+		if i > 0 {
+			stride := uintptr(unsafe.Pointer(&s[i])) - uintptr(unsafe.Pointer(&s[i-1]))
+			if stride != unsafe.Sizeof(s[0]) {
+				t.Errorf("ids backing not contiguous at %v", i)
+			}
+		}
+	}
+	f := scores.Rows()
+	for i := 1; i < len(f); i++ {
+		if uintptr(unsafe.Pointer(&f[i]))-uintptr(unsafe.Pointer(&f[i-1])) != unsafe.Sizeof(f[0]) {
+			t.Errorf("scores backing not contiguous at %v", i)
+		}
+	}
+}
+
+func Test_Queue(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	q := NewQueue[int](ac)
+	if _, ok := q.Pop(); ok {
+		t.Errorf("empty pop")
+	}
+
+	// FIFO order across several block boundaries, interleaved with pops so
+	// drained blocks get recycled.
+	const n = queueBlockCap*3 + 17
+	next := 0
+	for i := 0; i < n; i++ {
+		q.Push(i)
+		if i%3 == 0 {
+			if v, ok := q.Pop(); !ok || v != next {
+				t.Fatalf("pop %v: %v", next, v)
+			}
+			next++
+		}
+	}
+	for ; q.Len() > 0; next++ {
+		if v, ok := q.Pop(); !ok || v != next {
+			t.Fatalf("pop %v: %v", next, v)
+		}
+	}
+	if next != n {
+		t.Errorf("drained %v of %v", next, n)
+	}
+
+	// heap pointees survive: the blocks live in arena memory, so the queue
+	// must register pointer elements for keep-alive.
+	pq := NewQueue[*PbItem](ac)
+	for i := 0; i < queueBlockCap+5; i++ {
+		pq.Push(&PbItem{Id: ac.Int(i)})
+		runtime.GC()
+	}
+	for i := 0; pq.Len() > 0; i++ {
+		v, _ := pq.Pop()
+		if *v.Id != i {
+			t.Fatalf("elem %v gced or corrupted", i)
+		}
+	}
+}
+
+func Test_Stack(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	// a two-level tree: the root's items each own a child PbData.
+	root := New[PbData](ac)
+	n := 5
+	for i := 0; i < n; i++ {
+		item := New[PbItem](ac)
+		item.Id = ac.Int(i)
+		root.Items = Append(ac, root.Items, item)
+	}
+
+	// iterative traversal with an explicit worklist instead of recursion.
+	st := NewStack[*PbItem](ac, 8)
+	for _, item := range root.Items {
+		st.Push(item)
+	}
+	if st.Len() != n {
+		t.Errorf("len: %v", st.Len())
+	}
+	if top, ok := st.Peek(); !ok || *top.Id != n-1 {
+		t.Errorf("peek")
+	}
+
+	visited := 0
+	for {
+		item, ok := st.Pop()
+		if !ok {
+			break
+		}
+		if *item.Id != n-1-visited {
+			t.Errorf("lifo order: %v", *item.Id)
+		}
+		visited++
+	}
+	if visited != n {
+		t.Errorf("visited: %v", visited)
+	}
+	if _, ok := st.Peek(); ok {
+		t.Errorf("peek on empty")
+	}
+
+	// pushing past the capacity grows transparently.
+	small := NewStack[int](ac, 2)
+	for i := 0; i < 100; i++ {
+		small.Push(i)
+	}
+	for i := 99; i >= 0; i-- {
+		if v, _ := small.Pop(); v != i {
+			t.Errorf("grown stack order: %v != %v", v, i)
+		}
+	}
+}
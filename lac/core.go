@@ -10,13 +10,44 @@
 package lac
 
 import (
+	"bytes"
 	"fmt"
 	"math"
+	"math/bits"
 	"reflect"
+	"runtime"
+	"strings"
 	"sync/atomic"
 	"unsafe"
 )
 
+// Global accounting
+
+// In a server with thousands of allocators the total arena memory can
+// silently exceed budget, so we account all reserved chunk bytes globally.
+var (
+	globalReserved atomic.Int64
+	globalLimit    atomic.Int64
+)
+
+// TotalReserved returns the bytes currently reserved by all lac chunks,
+// both pooled and in-use.
+func TotalReserved() int64 {
+	return globalReserved.Load()
+}
+
+// SetGlobalLimit sets a soft limit on the total reserved bytes.
+// Once exceeded, AllocatorPool.Get returns nil so new allocators fall back
+// to the native heap until enough chunks are freed. 0 means no limit.
+func SetGlobalLimit(bytes int64) {
+	globalLimit.Store(bytes)
+}
+
+func overGlobalLimit() bool {
+	l := globalLimit.Load()
+	return l > 0 && globalReserved.Load() > l
+}
+
 // Chunk Pool
 
 type chunk []byte
@@ -44,6 +75,7 @@ func newChunkPool(name string, logger Logger, chunkSz, defaultChunks, chunksCap
 	r.New = func() *sliceHeader {
 		c := make(chunk, 0, chunkSz)
 		r.Stats.TotalCreated.Add(1)
+		globalReserved.Add(int64(chunkSz))
 		return (*sliceHeader)(unsafe.Pointer(&c))
 	}
 
@@ -63,6 +95,17 @@ type AllocatorPool struct {
 	chunkPool *ChunkPool
 	Name      string
 
+	// LargeAlloc, when set, supplies the dedicated chunks for allocations
+	// larger than ChunkSize, e.g. a FileBackedChunkAllocator spilling them
+	// to memory-mapped files. nil keeps them on the heap.
+	LargeAlloc LargeChunkAllocator
+
+	// live allocators with their Get-site stacks, for ReportLeaks.
+	// keyed by address so the records don't keep an abandoned allocator
+	// alive, letting the debug finalizer fire. debug mode only.
+	liveLock spinLock
+	liveAcs  map[uintptr]acquireRecord
+
 	Stats struct {
 		TotalCreatedAc atomic.Int64
 		ChunksUsed     atomic.Int64
@@ -94,6 +137,8 @@ func NewAllocatorPool(name string, logger Logger, lacCap, chunkSz, defaultChunks
 
 type Allocator struct {
 	refCnt     atomic.Int32
+	borrows    atomic.Int32
+	generation atomic.Int64 // bumped every reset, see Weak
 	chunks     []*sliceHeader
 	chunkPool  *ChunkPool
 	chunksLock spinLock
@@ -115,7 +160,164 @@ type Allocator struct {
 	externalMap    weakUniqQueue[any]
 	externalFunc   weakUniqQueue[any]
 
-	dbgScanObjs weakUniqQueue[any]
+	// in-cycle free lists for AllocRecyclable/Free, one per size class.
+	// NOTE: from the native heap, never from Lac itself.
+	freeLists [numRecycleClasses][]unsafe.Pointer
+	freeLock  spinLock
+
+	dbgScanObjs    weakUniqQueue[any]
+	dbgScanSkipped int64
+
+	// DbgAllocTrace is invoked on every typed allocation with the size and type.
+	// useful to dump a per-allocation timeline that aggregate stats can't give.
+	// must be cheap to skip when unset; cleared on reset.
+	DbgAllocTrace func(size int, typ reflect.Type)
+
+	// sampling trace: every sampleRate-th allocation is recorded into a small
+	// ring buffer. unlike DbgAllocTrace it skips most allocations, so it is
+	// cheap enough for production profiling.
+	sampleRate int
+	sampleCnt  int64
+	sampleLock spinLock
+	samples    []SampledAlloc
+	samplePos  int
+
+	// the entry object of the arena graph, see SetRoot/Save.
+	root     unsafe.Pointer
+	rootType reflect.Type
+
+	// ranges of released chunks per generation, debug mode only. see
+	// CheckGeneration.
+	staleRanges []genRange
+
+	// caps a single Append grow step in bytes, see SetMaxGrowStep. 0 = off.
+	maxGrowStep int
+
+	// geometric chunk growth schedule, see SetGeometricChunks. cap 0 = off.
+	geomNext int64
+	geomCap  int64
+
+	// self-tuned chunk size, see AdaptiveChunkSize. 0 = pool default.
+	// deliberately survives reset: the whole point is carrying what one
+	// cycle learned into the next.
+	adaptSize int64
+
+	// power-of-two allocation size buckets, see DbgSizeHistogram.
+	// debug mode only, cleared on reset.
+	sizeHist [sizeHistBuckets]int64
+
+	// reusable scratch for NewStringf, so formatting needs no heap string.
+	// NOTE: from the native heap, never from Lac itself.
+	fmtLock spinLock
+	fmtBuf  bytes.Buffer
+
+	// live regions of the cycle for DbgCheckOverlap.
+	// NOTE: from the native heap, never from Lac itself.
+	regionLock spinLock
+	regions    []dbgRegion
+
+	// chunks stamped with canaries at the last reset, verified at the next
+	// one. the references also keep the freed memory alive until then.
+	// see DbgResetCanaries.
+	canaried []*sliceHeader
+
+	// the secondary tier taking allocations once the primary budget is used,
+	// see WithOverflow. released together with this allocator.
+	overflow       *Allocator
+	overflowBudget int64
+	primaryUsed    int64
+
+	// deterministic allocators never reuse pooled chunks and pattern-fill
+	// fresh ones, see NewDeterministic. survives reset.
+	deterministic bool
+
+	// one mapping per allocation in guard-page mode, unmapped on reset.
+	// NOTE: from the native heap, never from Lac itself.
+	guardLock     spinLock
+	guardMappings [][]byte
+
+	// the borrow on the parent when this allocator is a Branch child,
+	// returned on reset so the parent unfreezes.
+	parentToken *BorrowToken
+}
+
+// SampledAlloc is one record of the sampling trace.
+type SampledAlloc struct {
+	Size   int
+	Caller string // short caller signature, "file.go:line"
+}
+
+const sampleBufCap = 256
+
+// enough buckets for any real allocation size (up to 2^47 bytes).
+const sizeHistBuckets = 48
+
+// recordAllocSize counts size into its power-of-two bucket:
+// bucket 1<<i covers sizes in (1<<(i-1), 1<<i].
+func (ac *Allocator) recordAllocSize(size int) {
+	b := 0
+	if size > 1 {
+		b = bits.Len(uint(size - 1))
+	}
+	if b >= sizeHistBuckets {
+		b = sizeHistBuckets - 1
+	}
+	atomic.AddInt64(&ac.sizeHist[b], 1)
+}
+
+// DbgSizeHistogram returns the allocation sizes of the current cycle as
+// bucket upper bound -> count, e.g. 64 -> 100 for a hundred allocations of
+// 33..64 bytes. the shape tells whether the workload clusters at sizes that
+// fit chunks poorly or would justify size classes. debug mode only: with
+// debug off, nothing is recorded and the map is empty.
+func (ac *Allocator) DbgSizeHistogram() map[int]int {
+	r := map[int]int{}
+	for i := range ac.sizeHist {
+		if n := atomic.LoadInt64(&ac.sizeHist[i]); n > 0 {
+			r[1<<i] = int(n)
+		}
+	}
+	return r
+}
+
+// EnableSampling starts recording every rate-th allocation,
+// keeping the most recent sampleBufCap samples. rate <= 0 disables sampling.
+func (ac *Allocator) EnableSampling(rate int) {
+	ac.sampleLock.Lock()
+	defer ac.sampleLock.Unlock()
+	ac.sampleRate = rate
+	ac.sampleCnt = 0
+	ac.samples = ac.samples[:0]
+	ac.samplePos = 0
+}
+
+// SampledAllocs returns a copy of the current sample ring buffer.
+func (ac *Allocator) SampledAllocs() []SampledAlloc {
+	ac.sampleLock.Lock()
+	defer ac.sampleLock.Unlock()
+	return append([]SampledAlloc(nil), ac.samples...)
+}
+
+func (ac *Allocator) maybeSample(size int) {
+	if atomic.AddInt64(&ac.sampleCnt, 1)%int64(ac.sampleRate) != 0 {
+		return
+	}
+
+	// skip maybeSample, alloc and the typed API to land on the user frame.
+	_, file, line, _ := runtime.Caller(3)
+	if i := strings.LastIndexByte(file, '/'); i >= 0 {
+		file = file[i+1:]
+	}
+	s := SampledAlloc{Size: size, Caller: fmt.Sprintf("%s:%d", file, line)}
+
+	ac.sampleLock.Lock()
+	if len(ac.samples) < sampleBufCap {
+		ac.samples = append(ac.samples, s)
+	} else {
+		ac.samples[ac.samplePos] = s
+		ac.samplePos = (ac.samplePos + 1) % sampleBufCap
+	}
+	ac.sampleLock.Unlock()
 }
 
 func newLac(acPool *AllocatorPool) *Allocator {
@@ -139,19 +341,82 @@ func newLac(acPool *AllocatorPool) *Allocator {
 	return ac
 }
 
+// alignSize returns the aligned byte size alloc actually advances for a request.
+func alignSize(need int) int {
+	if need%ptrSize != 0 {
+		// round up
+		return (need + ptrSize + 1) & ^(ptrSize - 1)
+	}
+	return need
+}
+
+// AllocSize returns the aligned byte size alloc advances for one object of
+// t, so manual reserve math needs no guesswork about the rounding:
+// Reserve(count * AllocSize(t)) is exact.
+func AllocSize(t reflect.Type) int {
+	return alignSize(int(t.Size()))
+}
+
+// AllocSizeOf is the generic variant of AllocSize.
+func AllocSizeOf[T any]() int {
+	var t T
+	return alignSize(int(unsafe.Sizeof(t)))
+}
+
 // alloc auto select single-thread or multi-thread algo.
 // multi-thread version uses lock-free algorithm to reduce locking.
+//
+// the overwhelmingly common case is a single-threaded allocation that fits the
+// current chunk, so it is kept branch-minimal and small enough to be inlined;
+// everything else goes through allocSlow.
 func (ac *Allocator) alloc(need int, zero bool) unsafe.Pointer {
 	if need == 0 && BugfixCorruptOtherMem {
 		return nil
 	}
 
-	needAligned := need
-	if need%ptrSize != 0 {
-		// round up
-		needAligned = (need + ptrSize + 1) & ^(ptrSize - 1)
+	needAligned := alignSize(need)
+
+	if ac.sampleRate > 0 {
+		ac.maybeSample(need)
+	}
+
+	if ac.acPool.debugMode {
+		ac.recordAllocSize(need)
+	}
+
+	// past the primary budget the overflow tier takes the allocation.
+	if ov := ac.overflow; ov != nil {
+		if atomic.AddInt64(&ac.primaryUsed, int64(needAligned)) > atomic.LoadInt64(&ac.overflowBudget) {
+			atomic.AddInt64(&ac.primaryUsed, -int64(needAligned))
+			return ov.alloc(need, zero)
+		}
+	}
+
+	if DbgGuardPages && guardPagesSupported {
+		if ptr := ac.guardedAlloc(need); ptr != nil {
+			return ptr
+		}
 	}
 
+	if ac.refCnt.Load() == 1 && ac.curChunk != nil {
+		header := (*sliceHeader)(ac.curChunk)
+		if header.Len+int64(needAligned) <= header.Cap {
+			ptr := unsafe.Add(header.Data, header.Len)
+			header.Len += int64(needAligned)
+			if zero {
+				memclrNoHeapPointers(ptr, uintptr(needAligned))
+			}
+			if DbgCheckOverlap && ac.acPool.debugMode {
+				ac.dbgRecordRegion(ptr, needAligned)
+			}
+			return ptr
+		}
+	}
+
+	return ac.allocSlow(need, needAligned, zero)
+}
+
+func (ac *Allocator) allocSlow(need, needAligned int, zero bool) unsafe.Pointer {
 	chunkPool := ac.chunkPool
 	var header, new_ *sliceHeader
 	var len_, cap_ int64
@@ -167,11 +432,10 @@ func (ac *Allocator) alloc(need int, zero bool) unsafe.Pointer {
 			}
 
 			if len_+int64(needAligned) > cap_ {
-				if needAligned > chunkPool.ChunkSize {
-					t := make(chunk, 0, need)
-					new_ = (*sliceHeader)(unsafe.Pointer(&t))
+				if sz := ac.nextChunkSize(needAligned); sz > chunkPool.ChunkSize {
+					new_ = ac.newLargeChunk(sz)
 				} else {
-					new_ = chunkPool.Get()
+					new_ = ac.newPooledChunk()
 				}
 				ac.curChunk = unsafe.Pointer(new_)
 				ac.chunks = append(ac.chunks, new_)
@@ -181,6 +445,9 @@ func (ac *Allocator) alloc(need int, zero bool) unsafe.Pointer {
 				if zero {
 					memclrNoHeapPointers(ptr, uintptr(needAligned))
 				}
+				if DbgCheckOverlap && ac.acPool.debugMode {
+					ac.dbgRecordRegion(ptr, needAligned)
+				}
 				return ptr
 			}
 		}
@@ -196,18 +463,21 @@ func (ac *Allocator) alloc(need int, zero bool) unsafe.Pointer {
 		}
 
 		if len_+int64(needAligned) > cap_ {
-			if needAligned > chunkPool.ChunkSize {
-				t := make(chunk, 0, need)
-				new_ = (*sliceHeader)(unsafe.Pointer(&t))
+			if sz := ac.nextChunkSize(needAligned); sz > chunkPool.ChunkSize {
+				new_ = ac.newLargeChunk(sz)
 			} else {
-				new_ = chunkPool.Get()
+				new_ = ac.newPooledChunk()
 			}
 			if atomic.CompareAndSwapPointer(&ac.curChunk, cur, unsafe.Pointer(new_)) {
 				ac.chunksLock.Lock()
 				ac.chunks = append(ac.chunks, new_)
 				ac.chunksLock.Unlock()
 			} else if new_.Cap == int64(chunkPool.ChunkSize) {
-				chunkPool.Put(new_)
+				if DisablePooling || !chunkPool.Put(new_) {
+					globalReserved.Add(-new_.Cap)
+				}
+			} else {
+				ac.freeLargeChunk(new_)
 			}
 		} else {
 			if atomic.CompareAndSwapInt64(&header.Len, len_, len_+int64(needAligned)) {
@@ -215,21 +485,221 @@ func (ac *Allocator) alloc(need int, zero bool) unsafe.Pointer {
 				if zero {
 					memclrNoHeapPointers(ptr, uintptr(needAligned))
 				}
+				if DbgCheckOverlap && ac.acPool.debugMode {
+					ac.dbgRecordRegion(ptr, needAligned)
+				}
 				return ptr
 			}
 		}
 	}
 }
 
+// guardedAlloc serves one allocation from its own guarded mapping.
+// mmap memory comes back zeroed, so the zero flag is implicitly honored.
+// returns nil to fall back to chunk allocation.
+func (ac *Allocator) guardedAlloc(need int) unsafe.Pointer {
+	ptr, mapping := guardAlloc(need)
+	if ptr == nil {
+		return nil
+	}
+	ac.guardLock.Lock()
+	ac.guardMappings = append(ac.guardMappings, mapping)
+	ac.guardLock.Unlock()
+	return ptr
+}
+
+// inGuardMapping reports whether addr points into a live guarded mapping.
+func (ac *Allocator) inGuardMapping(addr uintptr) bool {
+	ac.guardLock.Lock()
+	defer ac.guardLock.Unlock()
+	for _, m := range ac.guardMappings {
+		base := uintptr(unsafe.Pointer(&m[0]))
+		if addr >= base && addr < base+uintptr(len(m)) {
+			return true
+		}
+	}
+	return false
+}
+
+// growSliceInPlace tries to extend a slice backing (pre, cap in elements) to
+// newSz bytes by bumping the current chunk further, which is safe only when
+// the backing ends exactly at the chunk's used tail.
+// returns false if the caller must relocate the backing instead.
+func (ac *Allocator) growSliceInPlace(pre *sliceHeader, elemSz, newSz int, zero bool) bool {
+	if ac.refCnt.Load() != 1 || ac.curChunk == nil || pre.Data == nil {
+		return false
+	}
+
+	ck := (*sliceHeader)(ac.curChunk)
+	oldSz := alignSize(int(pre.Cap) * elemSz)
+	if uintptr(pre.Data)+uintptr(oldSz) != uintptr(ck.Data)+uintptr(ck.Len) {
+		return false
+	}
+
+	grown := int64(alignSize(newSz) - oldSz)
+	if ck.Len+grown > ck.Cap {
+		return false
+	}
+	ck.Len += grown
+
+	if zero {
+		used := elemSz * int(pre.Len)
+		memclrNoHeapPointers(unsafe.Add(pre.Data, used), uintptr(newSz-used))
+	}
+	return true
+}
+
 func (ac *Allocator) reset() {
+	if n := ac.borrows.Load(); n > 0 {
+		if WaitBorrowsOnReset {
+			for ac.borrows.Load() > 0 {
+				runtime.Gosched()
+			}
+		} else if ac.acPool.debugMode {
+			errorf(ac.acPool, "potential bug: reset with %v outstanding borrows", n)
+		}
+	}
+
 	if ac.acPool.debugMode {
+		ac.validateInvariants()
 		ac.debugCheck(true)
 		ac.dbgScanObjs.Clear()
+		ac.dbgScanSkipped = 0
+	}
+
+	// canaries of the previous cycle are verified before this cycle's chunks
+	// get stamped in turn. must run after debugCheck: stamping destroys the
+	// chunk contents.
+	if ac.acPool.debugMode && DbgResetCanaries {
+		ac.verifyCanaries()
+		ac.canaried = append(ac.canaried[:0], ac.chunks...)
+		ac.stampCanaries()
+	} else {
+		ac.canaried = nil
 	}
 
+	if AdaptiveChunkSize {
+		ac.adaptChunkSize(len(ac.chunks))
+	}
+
+	// remember the ranges these chunks covered so CheckGeneration can tell a
+	// retained pointer of this cycle apart from the next one's allocations.
+	if ac.acPool.debugMode {
+		gen := ac.generation.Load()
+		for _, c := range ac.chunks {
+			ac.staleRanges = append(ac.staleRanges,
+				genRange{uintptr(c.Data), uintptr(c.Data) + uintptr(c.Cap), gen})
+		}
+	} else {
+		ac.staleRanges = nil
+	}
+
+	ac.releaseChunks(ac.chunks)
+
+	// clear all ref
+	ac.chunks = resetSlice(ac.chunks)
+	ac.curChunk = nil
+
+	ac.DbgAllocTrace = nil
+	ac.EnableSampling(0)
+	ac.root = nil
+	ac.rootType = nil
+	ac.maxGrowStep = 0
+	atomic.StoreInt64(&ac.geomCap, 0)
+	ac.sizeHist = [sizeHistBuckets]int64{}
+	ac.regions = ac.regions[:0]
+
+	// drop the in-cycle free lists, the buffers die with the chunks.
+	for i := range ac.freeLists {
+		ac.freeLists[i] = ac.freeLists[i][:0]
+	}
+
+	// unmapping turns any use-after-free of a guarded object into a fault.
+	for _, m := range ac.guardMappings {
+		guardFree(m)
+	}
+	ac.guardMappings = nil
+
+	// clear externals
+	ac.externalPtr.Clear()
+	ac.externalSlice.Clear()
+	ac.externalMap.Clear()
+	ac.externalString.Clear()
+	ac.externalFunc.Clear()
+
+	// the overflow tier is torn down with its facade.
+	if ov := ac.overflow; ov != nil {
+		ac.overflow = nil
+		ov.Release()
+	}
+	atomic.StoreInt64(&ac.overflowBudget, 0)
+	atomic.StoreInt64(&ac.primaryUsed, 0)
+
+	// a Branch child unfreezes its parent here.
+	if t := ac.parentToken; t != nil {
+		ac.parentToken = nil
+		t.Return()
+	}
+
+	// invalidate all Weak handles of the finished cycle.
+	ac.generation.Add(1)
+
+	ac.refCnt.Store(1)
+}
+
+type dbgRegion struct {
+	start, end uintptr
+}
+
+// dbgRecordRegion asserts the fresh region is disjoint from every region
+// already handed out in this cycle, then records it. see DbgCheckOverlap.
+func (ac *Allocator) dbgRecordRegion(ptr unsafe.Pointer, size int) {
+	start, end := uintptr(ptr), uintptr(ptr)+uintptr(size)
+	ac.regionLock.Lock()
+	for _, r := range ac.regions {
+		if start < r.end && r.start < end {
+			ac.regionLock.Unlock()
+			errorf(ac.acPool, "potential bug: allocation [%x,%x) overlaps live [%x,%x)", start, end, r.start, r.end)
+			return
+		}
+	}
+	ac.regions = append(ac.regions, dbgRegion{start, end})
+	ac.regionLock.Unlock()
+}
+
+// validateInvariants asserts the chunk bookkeeping the alloc paths rely on:
+// no nil entries, every chunk's used size within its cap, no chunk smaller
+// than ChunkSize, and curChunk being the newest chunk. debug mode only,
+// called when the allocator quiesces in reset to catch regressions in the
+// chunk-advance logic early instead of as corrupted objects later.
+func (ac *Allocator) validateInvariants() {
+	chunkSz := int64(ac.chunkPool.ChunkSize)
+	for i, ck := range ac.chunks {
+		if ck == nil {
+			errorf(ac.acPool, "potential bug: nil chunk at %v of %v", i, len(ac.chunks))
+			return
+		}
+		if ck.Len < 0 || ck.Len > ck.Cap {
+			errorf(ac.acPool, "potential bug: chunk %v used %v beyond cap %v", i, ck.Len, ck.Cap)
+			return
+		}
+		if ck.Cap < chunkSz {
+			errorf(ac.acPool, "potential bug: chunk %v undersized: %v < %v", i, ck.Cap, chunkSz)
+			return
+		}
+	}
+	if cur := (*sliceHeader)(ac.curChunk); cur != nil {
+		if n := len(ac.chunks); n == 0 || ac.chunks[n-1] != cur {
+			errorf(ac.acPool, "potential bug: curChunk is not the newest chunk")
+		}
+	}
+}
+
+// releaseChunks puts the chunks back to the pool or leaves them to the GC.
+func (ac *Allocator) releaseChunks(chunks []*sliceHeader) {
 	stats := &ac.acPool.Stats
 
-	for _, ck := range ac.chunks {
+	for _, ck := range chunks {
 		stats.AllocBytes.Add(ck.Len)
 		ck.Len = 0
 
@@ -240,34 +710,154 @@ func (ac *Allocator) reset() {
 
 			if ac.acPool.debugMode {
 				diagnosisChunkPool.Put(ck)
+			} else if DisablePooling || ac.deterministic {
+				globalReserved.Add(-ck.Cap)
 			} else {
 				if ZeroMemOnFree {
 					memclrNoHeapPointers(ck.Data, uintptr(ck.Cap))
 				}
-				ac.acPool.chunkPool.Put(ck)
+				if !ac.acPool.chunkPool.Put(ck) {
+					globalReserved.Add(-ck.Cap)
+				}
 			}
 		} else {
 			if ac.acPool.debugMode {
+				// NOTE: file-backed chunks stay mapped here to keep the
+				// use-after-free diagnosis working.
 				diagnosisChunkPool.Put(ck)
 			} else {
-				// recycle by GC.
+				ac.freeLargeChunk(ck)
 			}
 			stats.ChunksMiss.Add(1)
 		}
 	}
+}
 
-	// clear all ref
-	ac.chunks = resetSlice(ac.chunks)
-	ac.curChunk = nil
+// nextChunkSize returns the capacity for the next chunk to create: the
+// fixed ChunkSize (or the request itself when larger) normally, or the
+// geometric schedule when enabled, each chunk doubling up to the cap.
+func (ac *Allocator) nextChunkSize(needAligned int) int {
+	sz := ac.chunkPool.ChunkSize
 
-	// clear externals
-	ac.externalPtr.Clear()
-	ac.externalSlice.Clear()
-	ac.externalMap.Clear()
-	ac.externalString.Clear()
-	ac.externalFunc.Clear()
+	if cap_ := atomic.LoadInt64(&ac.geomCap); cap_ > 0 {
+		for {
+			cur := atomic.LoadInt64(&ac.geomNext)
+			next := cur * 2
+			if next > cap_ {
+				next = cap_
+			}
+			if atomic.CompareAndSwapInt64(&ac.geomNext, cur, next) {
+				sz = int(cur)
+				break
+			}
+		}
+	}
 
-	ac.refCnt.Store(1)
+	if AdaptiveChunkSize {
+		if a := atomic.LoadInt64(&ac.adaptSize); a > 0 {
+			sz = int(a)
+		}
+	}
+
+	if needAligned > sz {
+		sz = needAligned
+	}
+	return sz
+}
+
+// adaptive chunk sizing thresholds. a cycle consuming more chunks than
+// adaptGrowAt doubles the next cycle's chunk size up to adaptSizeCap; one
+// consuming fewer than adaptShrinkAt halves it, floored at the pool's
+// ChunkSize. cycles inside the band leave the size alone — the hysteresis
+// that keeps a workload sitting on a single threshold from oscillating
+// between two sizes every reset.
+const (
+	adaptGrowAt   = 8
+	adaptShrinkAt = 2
+	adaptSizeCap  = 8 << 20
+)
+
+// adaptChunkSize retunes adaptSize from the finished cycle's chunk count.
+// called on reset, so the chunk list is still the per-cycle high-water mark.
+func (ac *Allocator) adaptChunkSize(chunksUsed int) {
+	cur := atomic.LoadInt64(&ac.adaptSize)
+	if cur == 0 {
+		cur = int64(ac.chunkPool.ChunkSize)
+	}
+	next := cur
+	switch {
+	case chunksUsed > adaptGrowAt:
+		next = cur * 2
+		if next > adaptSizeCap {
+			next = adaptSizeCap
+		}
+	case chunksUsed < adaptShrinkAt:
+		next = cur / 2
+		if next < int64(ac.chunkPool.ChunkSize) {
+			next = int64(ac.chunkPool.ChunkSize)
+		}
+	}
+	atomic.StoreInt64(&ac.adaptSize, next)
+}
+
+// newPooledChunk gets a normal chunk, from the pool unless pooling is
+// disabled, in which case every chunk is freshly made so freed memory is
+// never reused within the process.
+func (ac *Allocator) newPooledChunk() *sliceHeader {
+	if DisablePooling || ac.deterministic {
+		t := make(chunk, 0, ac.chunkPool.ChunkSize)
+		globalReserved.Add(int64(ac.chunkPool.ChunkSize))
+		h := (*sliceHeader)(unsafe.Pointer(&t))
+		if ac.deterministic {
+			patternFill(h)
+		}
+		return h
+	}
+	return ac.chunkPool.Get()
+}
+
+// the byte un-zeroed deterministic memory reads back, see NewDeterministic.
+const detFillPattern = 0xAA
+
+// patternFill stamps a whole chunk with detFillPattern.
+func patternFill(h *sliceHeader) {
+	b := unsafe.Slice((*byte)(h.Data), h.Cap)
+	for i := range b {
+		b[i] = detFillPattern
+	}
+}
+
+// newLargeChunk makes a dedicated chunk for an over-sized allocation,
+// spilling to the pool's LargeAlloc when configured.
+// FIX: callers must pass the aligned size: a chunk sized to the raw need
+// can never satisfy the aligned request, leaving alloc spinning on new chunks.
+func (ac *Allocator) newLargeChunk(need int) *sliceHeader {
+	if MaxChunkSize > 0 && need > MaxChunkSize {
+		errorf(ac.acPool, "potential bug: dedicated chunk of %v exceeds MaxChunkSize %v, consider a StripedSlice", need, MaxChunkSize)
+	}
+	if la := ac.acPool.LargeAlloc; la != nil {
+		if h := la.AllocChunk(need); h != nil {
+			globalReserved.Add(int64(need))
+			return h
+		}
+	}
+	t := make(chunk, 0, need)
+	globalReserved.Add(int64(need))
+	h := (*sliceHeader)(unsafe.Pointer(&t))
+	if ac.deterministic {
+		patternFill(h)
+	}
+	return h
+}
+
+// freeLargeChunk hands a dedicated chunk back: heap ones to the GC,
+// file-backed ones to their mapping owner.
+func (ac *Allocator) freeLargeChunk(ck *sliceHeader) {
+	globalReserved.Add(-ck.Cap)
+	if la := ac.acPool.LargeAlloc; la != nil {
+		// a no-op for chunks it does not own.
+		la.FreeChunk(ck)
+	}
 }
 
 func (ac *Allocator) keepAlive(ptr interface{}) {
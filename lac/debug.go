@@ -13,8 +13,12 @@ import (
 	"fmt"
 	"math"
 	"reflect"
+	"runtime"
+	rtDebug "runtime/debug"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 	"unsafe"
 )
 
@@ -42,11 +46,11 @@ func (p *AllocatorPool) DumpStats(reset bool) string {
 	s := fmt.Sprintf(`
 [stats]name:%s, chunk_sz:%v,
 [total]new_chunks:%v,new_lacs:%v,
-[chunks]utilization:%.2f, used:%v, miss:%v, pooled:%v,
+[chunks]utilization:%.2f, hit_rate:%.2f, used:%v, miss:%v, pooled:%v,
 [lac]pooled:%v`,
 		p.Name, p.chunkPool.ChunkSize,
 		p.chunkPool.Stats.TotalCreated.Load(), p.Stats.TotalCreatedAc.Load(),
-		utilization, p.Stats.ChunksUsed.Load(), p.Stats.ChunksMiss.Load(), len(p.chunkPool.pool),
+		utilization, p.PoolHitRate(), p.Stats.ChunksUsed.Load(), p.Stats.ChunksMiss.Load(), len(p.chunkPool.pool),
 		len(p.pool),
 	)
 	s = strings.ReplaceAll(s, "\n", "")
@@ -60,6 +64,72 @@ func (p *AllocatorPool) DumpStats(reset bool) string {
 	return s
 }
 
+// IsEmpty reports whether the arena holds no live allocations, i.e. it is
+// back to the clean state a reset leaves behind. handy for asserting reset
+// discipline in tests without poking at the chunk internals.
+func (ac *Allocator) IsEmpty() bool {
+	if ac == nil {
+		return true
+	}
+
+	ac.chunksLock.Lock()
+	defer ac.chunksLock.Unlock()
+	for _, c := range ac.chunks {
+		if c.Len > 0 {
+			return false
+		}
+	}
+	return len(ac.guardMappings) == 0
+}
+
+// Used returns the bytes currently allocated from the arena: the sum of all
+// chunk advances of the cycle, including alignment rounding.
+func (ac *Allocator) Used() int64 {
+	if ac == nil {
+		return 0
+	}
+	ac.chunksLock.Lock()
+	defer ac.chunksLock.Unlock()
+	var used int64
+	for _, c := range ac.chunks {
+		used += c.Len
+	}
+	return used
+}
+
+// Stats summarizes one allocator for monitoring. the native passthrough
+// identifies itself, so dashboards can tell real-arena from passthrough usage.
+func (ac *Allocator) Stats() string {
+	if ac == nil {
+		return "[lac]mode:native"
+	}
+
+	ac.chunksLock.Lock()
+	var used int64
+	for _, c := range ac.chunks {
+		used += c.Len
+	}
+	n := len(ac.chunks)
+	ac.chunksLock.Unlock()
+
+	r := fmt.Sprintf("[lac]mode:linear, chunks:%v, used:%v", n, used)
+	if ov := ac.overflow; ov != nil {
+		r += fmt.Sprintf(", budget:%v, overflow:{%v}", atomic.LoadInt64(&ac.overflowBudget), ov.Stats())
+	}
+	return r
+}
+
+// PoolHitRate reports what fraction of chunk acquisitions over the pool's life
+// were satisfied from the pool versus freshly allocated. a low rate means the
+// pool is too small or chunks keep being dropped (large chunks, bounded cap),
+// and directly guides prewarm and pool-size tuning.
+func (p *AllocatorPool) PoolHitRate() float64 {
+	if p == nil {
+		return 0
+	}
+	return p.chunkPool.HitRate()
+}
+
 // DebugCheck check if all items from pool are all returned to pool.
 // useful for leak-checking.
 func (p *AllocatorPool) DebugCheck() {
@@ -73,6 +143,63 @@ func (p *AllocatorPool) DebugCheck() {
 	}
 }
 
+const (
+	dbgCanaryValue  uint64 = 0xdeadbeefcafebabe
+	dbgCanaryStride        = 1024
+)
+
+// stampCanaries writes the canary value at known offsets of every chunk
+// being freed by this reset. see DbgResetCanaries.
+func (ac *Allocator) stampCanaries() {
+	for _, ck := range ac.canaried {
+		for off := int64(0); off+8 <= ck.Cap; off += dbgCanaryStride {
+			*(*uint64)(unsafe.Add(ck.Data, off)) = dbgCanaryValue
+		}
+	}
+}
+
+// verifyCanaries asserts the canaries stamped at the previous reset are
+// intact and reports the chunk and offset of the first corruption: a
+// clobbered canary means some goroutine retained a pointer across the reset
+// and wrote through it.
+func (ac *Allocator) verifyCanaries() {
+	for i, ck := range ac.canaried {
+		for off := int64(0); off+8 <= ck.Cap; off += dbgCanaryStride {
+			if *(*uint64)(unsafe.Add(ck.Data, off)) != dbgCanaryValue {
+				ac.canaried = nil
+				errorf(ac.acPool, "potential bug: freed chunk %v written at offset %v after reset (canary clobbered)", i, off)
+				return
+			}
+		}
+	}
+}
+
+// inUsedRange reports whether addr lies in the currently allocated part of
+// any chunk (or a guard mapping), i.e. was not rolled back by a Leave.
+func (ac *Allocator) inUsedRange(addr uintptr) bool {
+	for _, h := range ac.chunks {
+		if addr >= uintptr(h.Data) && addr < uintptr(h.Data)+uintptr(h.Len) {
+			return true
+		}
+	}
+	return len(ac.guardMappings) > 0 && ac.inGuardMapping(addr)
+}
+
+// maybeWarnLargeObject logs type and size of an allocation crossing the
+// DbgWarnLargeObject threshold. a warning, not an error — nothing is broken
+// yet, so unlike errorf it never panics.
+func (ac *Allocator) maybeWarnLargeObject(size int, typ reflect.Type) {
+	if DbgWarnLargeObject <= 0 || size <= DbgWarnLargeObject || !ac.acPool.debugMode {
+		return
+	}
+	msg := fmt.Sprintf("Lac: %v of %v bytes exceeds DbgWarnLargeObject (%v); objects this big fragment the arena, consider the heap", typ, size, DbgWarnLargeObject)
+	if l := ac.acPool.Logger; l != nil {
+		l.Errorf("%s", msg)
+	} else {
+		fmt.Printf("%s\n", msg)
+	}
+}
+
 // CheckExternalPointers is useful for if you want to check external pointers but don't want to invalidate pointers.
 // e.g. using lac as memory allocator for config data globally.
 func (ac *Allocator) CheckExternalPointers() {
@@ -80,9 +207,56 @@ func (ac *Allocator) CheckExternalPointers() {
 }
 
 func (ac *Allocator) debugScan(obj any) {
+	// pointer-free structs can't violate GC safety, skip them to keep the
+	// scan slice small. ContainsPointers caches the answer per type.
+	if !ContainsPointers(reflect.TypeOf(obj).Elem()) {
+		return
+	}
+	if DbgMaxScanObjs > 0 && len(ac.dbgScanObjs.slice) >= DbgMaxScanObjs {
+		ac.dbgScanSkipped++
+		if ac.dbgScanSkipped%int64(DbgScanSampleRate) != 0 {
+			return
+		}
+	}
 	ac.dbgScanObjs.Put(obj)
 }
 
+// Some std types hold pointers to process-global singletons, e.g. time.Time
+// keeps a *time.Location pointing at time.UTC or time.Local. they are external
+// but always reachable, so flagging them as dangled externals is a false positive.
+var knownGlobalPtrs = struct {
+	sync.Mutex
+	ptrs []uintptr
+}{}
+
+// RegisterGlobals marks process-global pointers as always-valid externals
+// for the pointer check, so types like time.Time can live in lac.
+// time.UTC and time.Local are registered by default.
+func RegisterGlobals(ptrs ...any) {
+	knownGlobalPtrs.Lock()
+	defer knownGlobalPtrs.Unlock()
+	for _, p := range ptrs {
+		if d := data(p); d != nil {
+			knownGlobalPtrs.ptrs = append(knownGlobalPtrs.ptrs, uintptr(d))
+		}
+	}
+}
+
+func init() {
+	RegisterGlobals(time.UTC, time.Local)
+}
+
+func isKnownGlobal(addr uintptr) bool {
+	knownGlobalPtrs.Lock()
+	defer knownGlobalPtrs.Unlock()
+	for _, p := range knownGlobalPtrs.ptrs {
+		if p == addr {
+			return true
+		}
+	}
+	return false
+}
+
 // Use 1 instead of nil or MaxUint64 to
 // 1. make non-nil check pass to allow the dereference of pointer.
 // 2. generate a recoverable panic.
@@ -97,6 +271,15 @@ const (
 	pointerTypeExternalMarked
 )
 
+// Owns reports whether p points into memory handed out by this allocator in
+// the current cycle.
+func (ac *Allocator) Owns(p unsafe.Pointer) bool {
+	if ac == nil || p == nil {
+		return false
+	}
+	return ac.checkPointerType(uintptr(p)) == pointerTypeLacInternal
+}
+
 func (ac *Allocator) checkPointerType(addr uintptr) pointerType {
 
 	if addr == 0 || addr == nonNilPanickyAddr {
@@ -113,18 +296,178 @@ func (ac *Allocator) checkPointerType(addr uintptr) pointerType {
 		}
 	}
 
+	if len(ac.guardMappings) > 0 && ac.inGuardMapping(addr) {
+		return pointerTypeLacInternal
+	}
+
+	// the overflow tier is part of the same facade, its memory is internal.
+	if ov := ac.overflow; ov != nil {
+		for _, h := range ov.chunks {
+			if addr >= uintptr(h.Data) && addr < uintptr(h.Data)+uintptr(h.Cap) {
+				return pointerTypeLacInternal
+			}
+		}
+	}
+
 	for _, c := range ac.externalPtr.slice {
 		if uintptr(c) == addr {
 			return pointerTypeExternalMarked
 		}
 	}
+
+	if isKnownGlobal(addr) {
+		return pointerTypeExternalMarked
+	}
 	return pointerTypeExternal
 }
 
+// genRange remembers the address range a chunk covered and the generation it
+// served. debug-mode chunks are parked in the put-only diagnosis pool and
+// never reused, so a recorded range stays unambiguous across cycles.
+type genRange struct {
+	start, end uintptr
+	gen        int64
+}
+
+// staleGen returns the generation addr was allocated in, ok=false when addr
+// was never part of a released chunk of this allocator.
+func (ac *Allocator) staleGen(addr uintptr) (int64, bool) {
+	for _, r := range ac.staleRanges {
+		if addr >= r.start && addr < r.end {
+			return r.gen, true
+		}
+	}
+	return 0, false
+}
+
+// CheckGeneration reports whether ptr belongs to the current reset generation
+// of this allocator. a false result means the pointer was handed out in an
+// earlier cycle and now aliases recycled memory: the classic "held a pointer
+// across Reset" leak. only meaningful in debug mode, where released chunks
+// are never reused and their ranges are recorded; otherwise always true.
+func (ac *Allocator) CheckGeneration(ptr unsafe.Pointer) bool {
+	if ac == nil {
+		return true
+	}
+	_, stale := ac.staleGen(uintptr(ptr))
+	return !stale
+}
+
+// CheckStalePointers scans root like the release-time checker and reports the
+// first reachable pointer from a prior generation of this allocator. run it
+// on long-lived structures after a reset to find retention leaks at their
+// source rather than when the garbage is eventually read. debug mode only.
+func (ac *Allocator) CheckStalePointers(root interface{}) {
+	if ac == nil || !ac.acPool.debugMode {
+		return
+	}
+	ac.checkStale(reflect.ValueOf(root), map[uintptr]struct{}{})
+}
+
+func (ac *Allocator) checkStale(val reflect.Value, visited map[uintptr]struct{}) {
+	switch val.Kind() {
+	case reflect.Ptr:
+		if val.IsNil() || val.Pointer() == nonNilPanickyAddr {
+			return
+		}
+		addr := val.Pointer()
+		if gen, stale := ac.staleGen(addr); stale {
+			errorf(ac.acPool, "potential bug: pointer %x retained from generation %v, current is %v",
+				addr, gen, ac.generation.Load())
+		}
+		// mark before recursing, otherwise a cyclic graph recurses forever.
+		if _, ok := visited[addr]; ok {
+			return
+		}
+		visited[addr] = struct{}{}
+		if val.Elem().Kind() == reflect.Struct || val.Elem().Kind() == reflect.Slice {
+			ac.checkStale(val.Elem(), visited)
+		}
+
+	case reflect.Struct:
+		for i := 0; i < val.NumField(); i++ {
+			if f := val.Field(i); mayContainsPtr(f.Kind()) {
+				ac.checkStale(f, visited)
+			}
+		}
+
+	case reflect.Slice:
+		if val.Len() > 0 && mayContainsPtr(val.Type().Elem().Kind()) {
+			for i := 0; i < val.Len(); i++ {
+				ac.checkStale(val.Index(i), visited)
+			}
+		}
+	}
+}
+
+// DbgValidateSlice panics if s's backing was handed out by an earlier cycle
+// of ac: the slice outlived a reset and now aliases recycled memory. slices
+// are the most commonly retained arena type, so this checks one directly at
+// its point of use instead of waiting for a full graph scan to stumble over
+// it. built on the same generation ranges as CheckGeneration, so it is debug
+// mode only and a no-op otherwise.
+func DbgValidateSlice[T any](ac *Allocator, s []T) {
+	if ac == nil || cap(s) == 0 {
+		return
+	}
+	h := (*sliceHeader)(unsafe.Pointer(&s))
+	if gen, stale := ac.staleGen(uintptr(h.Data)); stale {
+		errorf(ac.acPool, "potential bug: slice backing from generation %v retained into generation %v",
+			gen, ac.generation.Load())
+	}
+}
+
 type checkCtx struct {
 	checked            map[interface{}]struct{}
 	unsupportedTypes   map[string]struct{}
 	invalidatePointers bool
+
+	// auditing mode: collect all violations instead of failing on the first one.
+	collecting bool
+	path       []string
+	collected  []Violation
+}
+
+// Violation describes one external reference found by CheckPointersReport.
+type Violation struct {
+	Path string
+	Ptr  uintptr
+	Kind string
+}
+
+// violation either fails fast (normal mode) or records and continues (auditing mode).
+func (c *checkCtx) violation(kind string, ptr uintptr, desc string) error {
+	if c.collecting {
+		c.collected = append(c.collected, Violation{
+			Path: strings.Join(c.path, "."),
+			Ptr:  ptr,
+			Kind: kind,
+		})
+		return nil
+	}
+	return fmt.Errorf("unexpected external %s: %s", kind, desc)
+}
+
+// CheckPointersReport runs the external pointer check as an auditing tool:
+// it returns every external reference with its field path, without panicking
+// and without invalidating the object graph.
+// requires debug mode, like CheckExternalPointers.
+func (ac *Allocator) CheckPointersReport() []Violation {
+	ctx := &checkCtx{
+		checked:          map[interface{}]struct{}{},
+		unsupportedTypes: map[string]struct{}{},
+		collecting:       true,
+	}
+	for i := len(ac.dbgScanObjs.slice) - 1; i >= 0; i-- {
+		ptr := ac.dbgScanObjs.slice[i]
+		if _, ok := ctx.checked[ptr]; ok {
+			continue
+		}
+		// errors are collected in auditing mode.
+		ctx.path = append(ctx.path[:0], reflect.TypeOf(ptr).String())
+		_ = ac.checkRecursively(reflect.ValueOf(ptr), ctx)
+	}
+	return ctx.collected
 }
 
 // NOTE: all memories must be referenced by structs.
@@ -153,7 +496,7 @@ func (ac *Allocator) checkRecursively(val reflect.Value, ctx *checkCtx) error {
 		if val.Pointer() != nonNilPanickyAddr && !val.IsNil() {
 			pt := ac.checkPointerType(val.Pointer())
 			if pt == pointerTypeExternal {
-				return fmt.Errorf("unexpected external pointer: %+v", val)
+				return ctx.violation("pointer", val.Pointer(), fmt.Sprintf("%+v", val))
 			}
 
 			tp := val.Elem().Type()
@@ -163,10 +506,36 @@ func (ac *Allocator) checkRecursively(val reflect.Value, ctx *checkCtx) error {
 			}
 
 			if pt == pointerTypeLacInternal && tp.Kind() == reflect.Struct {
-				if err := ac.checkRecursively(val.Elem(), ctx); err != nil {
-					return err
+				// mark before recursing, otherwise a cyclic graph recurses forever.
+				k := interfaceOfUnexported(val)
+				if _, ok := ctx.checked[k]; !ok {
+					ctx.checked[k] = struct{}{}
+					if err := ac.checkRecursively(val.Elem(), ctx); err != nil {
+						return err
+					}
 				}
-				ctx.checked[interfaceOfUnexported(val)] = struct{}{}
+			}
+		}
+		return nil
+	}
+
+	// a map value can itself be a map (nested maps), validate it like a
+	// map field and recurse into its values.
+	if val.Kind() == reflect.Map {
+		m := unsafe.Pointer(val.Pointer())
+		found := false
+		for _, i := range ac.externalMap.slice {
+			if data(i) == m {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ctx.violation("map", uintptr(m), fmt.Sprintf("%+v", val))
+		}
+		for iter := val.MapRange(); iter.Next(); {
+			if err := ac.checkRecursively(iter.Value(), ctx); err != nil {
+				return err
 			}
 		}
 		return nil
@@ -180,6 +549,7 @@ func (ac *Allocator) checkRecursively(val reflect.Value, ctx *checkCtx) error {
 	if val.Kind() == reflect.Struct {
 		for i := 0; i < val.NumField(); i++ {
 			f := val.Field(i)
+			ctx.path = append(ctx.path, fieldName(i))
 
 			switch f.Kind() {
 			case reflect.Bool,
@@ -208,7 +578,9 @@ func (ac *Allocator) checkRecursively(val reflect.Value, ctx *checkCtx) error {
 					}
 					pt := ac.checkPointerType(uintptr(h.Data))
 					if !found && pt == pointerTypeExternal {
-						return fmt.Errorf("%s: unexpected external slice: %s", fieldName(i), f.String())
+						if err := ctx.violation("slice", uintptr(h.Data), f.String()); err != nil {
+							return fmt.Errorf("%s: %w", fieldName(i), err)
+						}
 					}
 					if pt == pointerTypeLacInternal {
 						for j := 0; j < f.Len(); j++ {
@@ -241,7 +613,9 @@ func (ac *Allocator) checkRecursively(val reflect.Value, ctx *checkCtx) error {
 					}
 				}
 				if !found {
-					return fmt.Errorf("%v: unexpected external map: %+v", fieldName(i), f)
+					if err := ctx.violation("map", uintptr(m), fmt.Sprintf("%+v", f)); err != nil {
+						return fmt.Errorf("%v: %w", fieldName(i), err)
+					}
 				}
 				for iter := f.MapRange(); iter.Next(); {
 					if err := ac.checkRecursively(iter.Value(), ctx); err != nil {
@@ -261,7 +635,9 @@ func (ac *Allocator) checkRecursively(val reflect.Value, ctx *checkCtx) error {
 					}
 					pt := ac.checkPointerType(uintptr(h.Data))
 					if !found && pt == pointerTypeExternal {
-						return fmt.Errorf("%s: unexpected external string: %s", fieldName(i), f.String())
+						if err := ctx.violation("string", uintptr(h.Data), f.String()); err != nil {
+							return fmt.Errorf("%s: %w", fieldName(i), err)
+						}
 					}
 				}
 				if ctx.invalidatePointers {
@@ -280,7 +656,9 @@ func (ac *Allocator) checkRecursively(val reflect.Value, ctx *checkCtx) error {
 						}
 					}
 					if !found {
-						return fmt.Errorf("%s: unexpected external func: %s", fieldName(i), f.String())
+						if err := ctx.violation("func", uintptr(data(p)), f.String()); err != nil {
+							return fmt.Errorf("%s: %w", fieldName(i), err)
+						}
 					}
 				}
 
@@ -288,6 +666,8 @@ func (ac *Allocator) checkRecursively(val reflect.Value, ctx *checkCtx) error {
 				msg := fmt.Sprintf("WARNING: pointer checking: unsupported type: %v, %v\n", fieldName(i), f.String())
 				ctx.unsupportedTypes[msg] = struct{}{}
 			}
+
+			ctx.path = ctx.path[:len(ctx.path)-1]
 		}
 	}
 	return nil
@@ -312,3 +692,157 @@ func dumpUnsupportedTypes(l Logger, ctx *checkCtx) {
 	}
 	unsupportedTypes.Unlock()
 }
+
+// Leak detection
+
+// acquireRecord remembers where and when an allocator left the pool.
+type acquireRecord struct {
+	stack string
+	at    time.Time
+}
+
+func (p *AllocatorPool) trackAcquire(ac *Allocator) {
+	p.liveLock.Lock()
+	if p.liveAcs == nil {
+		p.liveAcs = map[uintptr]acquireRecord{}
+	}
+	p.liveAcs[uintptr(unsafe.Pointer(ac))] = acquireRecord{stack: string(rtDebug.Stack()), at: time.Now()}
+	p.liveLock.Unlock()
+
+	// catches allocators dropped without Release, see reportAbandoned.
+	runtime.SetFinalizer(ac, (*Allocator).reportAbandoned)
+}
+
+func (p *AllocatorPool) trackRelease(ac *Allocator) {
+	p.liveLock.Lock()
+	delete(p.liveAcs, uintptr(unsafe.Pointer(ac)))
+	p.liveLock.Unlock()
+	runtime.SetFinalizer(ac, nil)
+}
+
+// reportAbandoned is the debug finalizer for an allocator that became
+// unreachable while still holding chunks: the user dropped it (or Reset it
+// and forgot it) instead of calling Release. the chunks die with the
+// allocator here, so the report is the only trace the leak leaves, and the
+// global accounting must be settled by hand.
+func (ac *Allocator) reportAbandoned() {
+	p := ac.acPool
+	addr := uintptr(unsafe.Pointer(ac))
+
+	p.liveLock.Lock()
+	rec, tracked := p.liveAcs[addr]
+	delete(p.liveAcs, addr)
+	p.liveLock.Unlock()
+
+	if len(ac.chunks) == 0 {
+		return
+	}
+	for _, ck := range ac.chunks {
+		globalReserved.Add(-ck.Cap)
+	}
+	if tracked {
+		// never panic on the GC's goroutine, warn instead.
+		msg := fmt.Sprintf("lac: allocator %p collected holding %v chunks without Release, got at:\n%s", ac, len(ac.chunks), rec.stack)
+		if p.Logger != nil {
+			p.Logger.Errorf("%s", msg)
+		} else {
+			fmt.Printf("%s", msg)
+		}
+	}
+}
+
+// ReportLeaks returns one report per allocator that left the pool more than
+// threshold ago without being released, with the Get-site stack. a long-run
+// teardown (or test) can print them to surface forgotten Release calls.
+// debug mode only; non-debug pools report nothing.
+func (p *AllocatorPool) ReportLeaks(threshold time.Duration) []string {
+	p.liveLock.Lock()
+	defer p.liveLock.Unlock()
+
+	var r []string
+	now := time.Now()
+	for addr, rec := range p.liveAcs {
+		if age := now.Sub(rec.at); age >= threshold {
+			r = append(r, fmt.Sprintf("allocator 0x%x not released for %v, got at:\n%s", addr, age, rec.stack))
+		}
+	}
+	return r
+}
+
+// ContentHash hashes the used portion of every chunk in order (FNV-1a),
+// for diffing runs that should be byte-identical: a hash that flickers
+// across such runs pinpoints nondeterministic initialization, typically
+// reading un-zeroed memory. the hash covers raw arena bytes, so it is
+// sensitive to allocation order (and to pointer values for self-referential
+// data); compare runs with identical allocation sequences only.
+func (ac *Allocator) ContentHash() uint64 {
+	const offset64, prime64 = 14695981039346656037, 1099511628211
+
+	h := uint64(offset64)
+	ac.chunksLock.Lock()
+	defer ac.chunksLock.Unlock()
+	for _, ck := range ac.chunks {
+		for _, c := range unsafe.Slice((*byte)(ck.Data), ck.Len) {
+			h = (h ^ uint64(c)) * prime64
+		}
+	}
+	return h
+}
+
+// Metrics
+
+// MetricsSnapshot is a point-in-time view of lac memory usage in plain
+// numbers, the arena-side counterpart of runtime/metrics: arena bytes are
+// invisible to the runtime's own accounting. feed it to any exporter; the
+// package deliberately imports none.
+type MetricsSnapshot struct {
+	// process-wide, shared by all pools.
+	ReservedBytes int64 // all chunk bytes reserved, pooled and in-use
+	LimitBytes    int64 // the SetGlobalLimit ceiling, 0 = none
+
+	// per pool.
+	ChunkSize         int
+	PooledChunks      int
+	PooledAllocators  int
+	ChunksCreated     int64 // cumulative
+	AllocatorsCreated int64 // cumulative
+	ChunksUsed        int64 // cumulative, reused + fresh
+	ChunksMiss        int64 // cumulative large-chunk fallbacks
+	AllocBytes        int64 // cumulative bytes handed out
+}
+
+// MetricsSnapshot captures the pool's current numbers. like DumpStats the
+// pool lengths are read without locking, momentary skew is fine for metrics.
+func (p *AllocatorPool) MetricsSnapshot() MetricsSnapshot {
+	return MetricsSnapshot{
+		ReservedBytes: globalReserved.Load(),
+		LimitBytes:    globalLimit.Load(),
+
+		ChunkSize:         p.chunkPool.ChunkSize,
+		PooledChunks:      len(p.chunkPool.pool),
+		PooledAllocators:  len(p.pool),
+		ChunksCreated:     p.chunkPool.Stats.TotalCreated.Load(),
+		AllocatorsCreated: p.Stats.TotalCreatedAc.Load(),
+		ChunksUsed:        p.Stats.ChunksUsed.Load(),
+		ChunksMiss:        p.Stats.ChunksMiss.Load(),
+		AllocBytes:        p.Stats.AllocBytes.Load(),
+	}
+}
+
+// ExportMetrics emits every metric as a name/value pair, prefixed with the
+// pool name (e.g. "lac_test_reserved_bytes"): the whole registration a
+// pull-style exporter needs, without this package importing one.
+func (p *AllocatorPool) ExportMetrics(emit func(name string, value int64)) {
+	s := p.MetricsSnapshot()
+	prefix := "lac_" + p.Name + "_"
+	emit(prefix+"reserved_bytes", s.ReservedBytes)
+	emit(prefix+"limit_bytes", s.LimitBytes)
+	emit(prefix+"chunk_size", int64(s.ChunkSize))
+	emit(prefix+"pooled_chunks", int64(s.PooledChunks))
+	emit(prefix+"pooled_allocators", int64(s.PooledAllocators))
+	emit(prefix+"chunks_created_total", s.ChunksCreated)
+	emit(prefix+"allocators_created_total", s.AllocatorsCreated)
+	emit(prefix+"chunks_used_total", s.ChunksUsed)
+	emit(prefix+"chunks_miss_total", s.ChunksMiss)
+	emit(prefix+"alloc_bytes_total", s.AllocBytes)
+}
@@ -10,12 +10,27 @@
 package lac
 
 import (
+	"fmt"
 	"runtime"
+	"runtime/debug"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+	"unsafe"
 )
 
-var acPool = NewAllocatorPool("test", nil, 10000, 64*1024, 32*1000, 64*1000)
+// the race detector's shadow memory multiplies the pool's chunk reserve
+// several times over, so scale it down there to stay runnable on small boxes.
+func newTestPool() *AllocatorPool {
+	if raceEnabled {
+		return NewAllocatorPool("test", nil, 1000, 64*1024, 32*10, 64*10)
+	}
+	return NewAllocatorPool("test", nil, 10000, 64*1024, 32*1000, 64*1000)
+}
+
+var acPool = newTestPool()
 var acPoolMu sync.RWMutex
 
 func Test_CheckArray(t *testing.T) {
@@ -232,6 +247,359 @@ func Test_CheckExternalEnum(t *testing.T) {
 	ac.Release()
 }
 
+func Test_CheckTimeTime(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	defer func() {
+		if err := recover(); err != nil {
+			t.Errorf("global *time.Location should not be flagged: %v", err)
+		}
+	}()
+
+	type D struct {
+		created time.Time
+	}
+	d := New[D](ac)
+	d.created = time.Now().In(time.Local)
+
+	tm := New[time.Time](ac)
+	*tm = time.Now().UTC()
+}
+
+func Test_CheckNestedMap(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	defer func() {
+		if err := recover(); err != nil {
+			t.Errorf("tracked nested map should pass: %v", err)
+		}
+	}()
+
+	type D struct {
+		m map[int]map[int]*int
+	}
+	d := New[D](ac)
+	d.m = NewNestedMap[int, int, *int](ac, 0)
+	inner := NewMap[int, *int](ac, 0)
+	inner[1] = ac.Int(1)
+	d.m[1] = inner
+}
+
+func Test_CheckUntrackedInnerMap(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("untracked inner map not detected")
+		}
+	}()
+
+	type D struct {
+		m map[int]map[int]*int
+	}
+	d := New[D](ac)
+	d.m = NewNestedMap[int, int, *int](ac, 0)
+	d.m[1] = make(map[int]*int) // not tracked
+
+	ac.Release()
+}
+
+func Test_ScanObjsSampling(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	DbgMaxScanObjs = 10
+	DbgScanSampleRate = 5
+	defer func() {
+		DbgMaxScanObjs = 0
+		DbgScanSampleRate = 100
+	}()
+
+	for i := 0; i < 100; i++ {
+		item := New[PbItem](ac)
+		item.Id = ac.Int(i)
+	}
+
+	// 10 fully tracked plus roughly 90/5 sampled.
+	if n := len(ac.dbgScanObjs.slice); n < 10 || n > 40 {
+		t.Errorf("tracked objs: %v", n)
+	}
+}
+
+func Test_CheckPointersReport(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+
+	type D struct {
+		a *int
+		b *int
+		s []*int
+	}
+	d := New[D](ac)
+	d.a = new(int)
+	d.b = ac.Int(1)
+	d.s = make([]*int, 1)
+
+	r := ac.CheckPointersReport()
+	if len(r) != 2 {
+		t.Errorf("violations: %+v", r)
+	}
+	kinds := map[string]bool{}
+	for _, v := range r {
+		kinds[v.Kind] = true
+		if v.Ptr == 0 {
+			t.Errorf("ptr not recorded: %+v", v)
+		}
+	}
+	if !kinds["pointer"] || !kinds["slice"] {
+		t.Errorf("kinds: %+v", r)
+	}
+
+	// auditing must not mutate the graph.
+	if *d.a != 0 || *d.b != 1 || len(d.s) != 1 {
+		t.Errorf("graph mutated")
+	}
+
+	// fix the violations so the release check passes.
+	d.a = Attach(ac, d.a)
+	d.s = Attach(ac, d.s)
+	ac.Release()
+}
+
+func Test_CheckOverlap(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	DbgCheckOverlap = true
+	defer func() { DbgCheckOverlap = false }()
+
+	t.Run("clean", func(t *testing.T) {
+		ac := acPool.Get()
+		defer ac.Release()
+
+		// many sizes across chunk advances and the large-chunk fallback.
+		for i := 0; i < 500; i++ {
+			_ = New[PbData](ac)
+			_ = ac.NewString("abc")
+		}
+		_ = NewSlice[byte](ac, 0, ac.chunkPool.ChunkSize*2)
+		for i := 0; i < 500; i++ {
+			_ = New[PbItem](ac)
+		}
+	})
+
+	t.Run("corrupted", func(t *testing.T) {
+		ac := acPool.Get()
+		defer func() {
+			if err := recover(); err == nil {
+				t.Errorf("overlap not detected")
+			}
+		}()
+
+		_ = New[PbData](ac)
+		// rewind the bump pointer: the next allocation must overlap.
+		(*sliceHeader)(ac.curChunk).Len -= 8
+		_ = New[PbData](ac)
+	})
+}
+
+func Test_IsEmpty(t *testing.T) {
+	acPool.EnableDebugMode(false)
+	ac := acPool.Get()
+
+	if !ac.IsEmpty() {
+		t.Errorf("fresh allocator not empty")
+	}
+	_ = New[PbItem](ac)
+	if ac.IsEmpty() {
+		t.Errorf("allocator with objects claims empty")
+	}
+
+	ac.reset()
+	if !ac.IsEmpty() {
+		t.Errorf("reset allocator not empty")
+	}
+	ac.Release()
+
+	if !Native().IsEmpty() {
+		t.Errorf("native allocator not empty")
+	}
+}
+
+func Test_ValidateInvariants(t *testing.T) {
+	acPool.EnableDebugMode(true)
+
+	t.Run("nil chunk", func(t *testing.T) {
+		ac := acPool.Get()
+		defer func() {
+			if err := recover(); err == nil {
+				t.Errorf("nil chunk not detected")
+			}
+		}()
+		_ = New[PbItem](ac)
+		ac.chunks = append(ac.chunks, nil)
+		ac.Release()
+	})
+
+	t.Run("overrun chunk", func(t *testing.T) {
+		ac := acPool.Get()
+		defer func() {
+			if err := recover(); err == nil {
+				t.Errorf("overrun chunk not detected")
+			}
+		}()
+		_ = New[PbItem](ac)
+		ac.chunks[0].Len = ac.chunks[0].Cap + 1
+		ac.Release()
+	})
+
+	t.Run("stale curChunk", func(t *testing.T) {
+		ac := acPool.Get()
+		defer func() {
+			if err := recover(); err == nil {
+				t.Errorf("stale curChunk not detected")
+			}
+		}()
+		_ = New[PbItem](ac)
+		ac.curChunk = unsafe.Pointer(&sliceHeader{})
+		ac.Release()
+	})
+
+	t.Run("healthy", func(t *testing.T) {
+		ac := acPool.Get()
+		defer ac.Release()
+		for i := 0; i < 1000; i++ {
+			_ = New[PbData](ac)
+		}
+		ac.validateInvariants()
+	})
+}
+
+func Test_StorePtrInternal(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	d := New[PbData](ac)
+	item := New[PbItem](ac)
+	StorePtrInternal(ac, &d.InUse, item)
+	if d.InUse != item {
+		t.Errorf("not stored")
+	}
+
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("external pointer not reported")
+		}
+		d.InUse = item // restore so the release check passes.
+	}()
+	StorePtrInternal(ac, &d.InUse, new(PbItem))
+}
+
+func Test_StorePtrInternalRelease(t *testing.T) {
+	acPool.EnableDebugMode(false)
+	defer acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	// release mode: a plain store, even for external pointers.
+	d := New[PbData](ac)
+	v := new(PbItem)
+	StorePtrInternal(ac, &d.InUse, v)
+	if d.InUse != v {
+		t.Errorf("not stored")
+	}
+}
+
+func Test_ResetWithBorrows(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("outstanding borrow not reported")
+		}
+	}()
+
+	_ = ac.Borrow()
+	ac.Release()
+}
+
+func Test_BorrowReturned(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+
+	defer func() {
+		if err := recover(); err != nil {
+			t.Errorf("returned borrow reported: %v", err)
+		}
+	}()
+
+	b := ac.Borrow()
+	b.Return()
+	b.Return() // extra returns are no-ops.
+	ac.Release()
+}
+
+func Test_ResetWaitsBorrows(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+
+	WaitBorrowsOnReset = true
+	defer func() { WaitBorrowsOnReset = false }()
+
+	b := ac.Borrow()
+	done := make(chan struct{})
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		b.Return()
+		close(done)
+	}()
+
+	ac.Release()
+	select {
+	case <-done:
+	default:
+		t.Errorf("reset did not wait for the borrow")
+	}
+}
+
+func Test_GuardPages(t *testing.T) {
+	if !guardPagesSupported {
+		t.Skip("no mmap/mprotect")
+	}
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	DbgGuardPages = true
+	defer func() { DbgGuardPages = false }()
+
+	item := New[PbItem](ac)
+	item.Id = ac.Int(1)
+	item.Name = ac.String("a")
+	if *item.Id != 1 || *item.Name != "a" {
+		t.Errorf("guarded alloc broken: %+v", item)
+	}
+	if ac.checkPointerType(uintptr(unsafe.Pointer(item))) != pointerTypeLacInternal {
+		t.Errorf("guarded obj flagged external")
+	}
+
+	// the byte right past the object lives in the guard page.
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("overrun not caught")
+		}
+	}()
+	end := alignSize(int(unsafe.Sizeof(*item)))
+	*(*byte)(unsafe.Add(unsafe.Pointer(item), end)) = 1
+}
+
 func Test_LacAsField(t *testing.T) {
 	acPool.EnableDebugMode(true)
 	ac := acPool.Get()
@@ -307,3 +675,494 @@ func Test_ShouldIgnoreFieldsOfMarkedExternal(t *testing.T) {
 	s.sub = Attach(ac, &D{i: new(int)})
 	ac.Release()
 }
+
+func Test_DisablePooling(t *testing.T) {
+	acPool.EnableDebugMode(false)
+	DisablePooling = true
+	defer func() { DisablePooling = false }()
+
+	ac := acPool.Get()
+	pooled := len(acPool.chunkPool.pool)
+	reserved := TotalReserved()
+
+	NewSlice[byte](ac, 0, acPool.chunkPool.ChunkSize/2)
+	if len(acPool.chunkPool.pool) != pooled {
+		t.Errorf("chunk pool should not be drained")
+	}
+
+	ac.Release()
+	if len(acPool.chunkPool.pool) != pooled {
+		t.Errorf("chunks should be dropped to the GC, not pooled")
+	}
+	if got := TotalReserved(); got != reserved {
+		t.Errorf("reserved bytes leaked: %v -> %v", reserved, got)
+	}
+}
+
+func Test_Branch(t *testing.T) {
+	acPool.EnableDebugMode(false)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	type S struct {
+		v    int
+		next *S
+	}
+	parent := New[S](ac)
+	parent.v = 1
+
+	child := ac.Branch()
+	if ac.borrows.Load() != 1 {
+		t.Errorf("parent should be frozen by the branch")
+	}
+
+	// speculative work: child objects may point into the parent.
+	spec := New[S](child)
+	spec.v = 2
+	spec.next = parent
+	if spec.next.v != 1 {
+		t.Errorf("child should read parent objects")
+	}
+
+	// discarding the child must not touch the parent.
+	child.Release()
+	if ac.borrows.Load() != 0 {
+		t.Errorf("branch release should unfreeze the parent")
+	}
+	if parent.v != 1 || parent.next != nil {
+		t.Errorf("parent corrupted by branch release")
+	}
+}
+
+func Test_DebugScanSkipsPtrFree(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	type plain struct {
+		a, b int
+	}
+	type withPtr struct {
+		p *int
+	}
+
+	before := len(ac.dbgScanObjs.slice)
+	for i := 0; i < 10; i++ {
+		New[plain](ac)
+	}
+	if got := len(ac.dbgScanObjs.slice); got != before {
+		t.Errorf("pointer-free structs should not be registered: %v -> %v", before, got)
+	}
+
+	New[withPtr](ac)
+	if got := len(ac.dbgScanObjs.slice); got != before+1 {
+		t.Errorf("pointer-holding structs should be registered: %v -> %v", before, got)
+	}
+}
+
+func Test_ReportLeaks(t *testing.T) {
+	// tests that intentionally abandon allocators pollute the shared pool's
+	// records, use a private one.
+	pool := NewAllocatorPool("leak", nil, 10, 64*1024, 4, 8)
+	pool.EnableDebugMode(true)
+
+	leaked := pool.Get()
+	ok := pool.Get()
+	ok.Release()
+
+	found := false
+	for _, r := range pool.ReportLeaks(0) {
+		if !strings.Contains(r, "Test_ReportLeaks") {
+			t.Errorf("report misses the Get-site stack: %s", r)
+		}
+		found = true
+	}
+	if !found {
+		t.Errorf("leaked allocator not reported")
+	}
+
+	leaked.Release()
+	if r := pool.ReportLeaks(0); len(r) != 0 {
+		t.Errorf("released allocators still reported: %v", r)
+	}
+}
+
+type logCapture struct {
+	lock spinLock
+	msgs []string
+}
+
+func (l *logCapture) Errorf(format string, args ...interface{}) {
+	l.lock.Lock()
+	l.msgs = append(l.msgs, fmt.Sprintf(format, args...))
+	l.lock.Unlock()
+}
+
+func Test_AbandonedAllocatorReported(t *testing.T) {
+	log := &logCapture{}
+	pool := NewAllocatorPool("abandon", log, 10, 64*1024, 4, 8)
+	pool.EnableDebugMode(true)
+
+	func() {
+		ac := pool.Get()
+		New[PbItem](ac)
+		// dropped without Release: the finalizer must surface the leak.
+	}()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		runtime.GC()
+		log.lock.Lock()
+		n := len(log.msgs)
+		log.lock.Unlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("abandoned allocator not reported")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	log.lock.Lock()
+	defer log.lock.Unlock()
+	if !strings.Contains(log.msgs[0], "without Release") ||
+		!strings.Contains(log.msgs[0], "Test_AbandonedAllocatorReported") {
+		t.Errorf("unexpected report: %s", log.msgs[0])
+	}
+}
+
+func Test_CheckerRespectsSliceLen(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	defer acPool.EnableDebugMode(false)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	d := New[PbData](ac)
+	d.Items = NewSlice[*PbItem](ac, 0, 4)
+	d.Items = Append(ac, d.Items, New[PbItem](ac))
+
+	// scribble garbage into the uninitialized cap region: the checker
+	// must not look past len. a heap pointer stands in for the stale
+	// garbage, it reports as external instead of faulting outright.
+	h := (*sliceHeader)(unsafe.Pointer(&d.Items))
+	garbage := unsafe.Add(h.Data, ptrSize*2)
+	*(*uintptr)(garbage) = uintptr(unsafe.Pointer(&PbItem{}))
+	ac.CheckExternalPointers()
+
+	// claiming a len that covers the unwritten elements must fail.
+	func() {
+		defer func() {
+			if e := recover(); e == nil {
+				t.Errorf("garbage pointer within len should be reported")
+			}
+		}()
+		SetLen(ac, &d.Items, 3)
+		ac.CheckExternalPointers()
+	}()
+
+	// repair the len so the release-time check stays clean.
+	*(*uintptr)(garbage) = 0
+	SetLen(ac, &d.Items, 1)
+}
+
+func Test_ContentHash(t *testing.T) {
+	build := func(pollute byte) uint64 {
+		ac := acPool.Get()
+		defer ac.Release()
+
+		// pollute the chunk, recycle it, then leak un-zeroed memory into
+		// the hashed region via a no-zero slice.
+		junk := NewSlice[byte](ac, 64, 64)
+		for i := range junk {
+			junk[i] = pollute
+		}
+		ac.Reset()
+		NewSlice[byte](ac, 64, 64)
+
+		s := NewSlice[int](ac, 0, 8)
+		for i := 0; i < 8; i++ {
+			s = Append(ac, s, i)
+		}
+		return ac.ContentHash()
+	}
+
+	if build(0xaa) != build(0xaa) {
+		t.Errorf("identical builds should hash identically")
+	}
+	if build(0xaa) == build(0x55) {
+		t.Errorf("un-zeroed leakage should change the hash")
+	}
+}
+
+// a large chunk landing mid-list must neither leak into the normal chunk
+// pool on reset (the cap guard in releaseChunks) nor survive as a stale
+// reference past len after resetSlice reclaims the chunk list.
+func Test_ResetWithMidListLargeChunk(t *testing.T) {
+	acPool.EnableDebugMode(false)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	chunkSz := acPool.chunkPool.ChunkSize
+	NewSlice[byte](ac, 0, chunkSz/2) // normal chunk
+	NewSlice[byte](ac, 0, chunkSz*3) // dedicated chunk, mid-list
+	NewSlice[byte](ac, 0, chunkSz/2) // normal chunk after it
+
+	if len(ac.chunks) != 3 {
+		t.Fatalf("chunks: %v", len(ac.chunks))
+	}
+
+	ac.Reset()
+
+	full := ac.chunks[:cap(ac.chunks)]
+	for i, ck := range full {
+		if ck != nil {
+			t.Errorf("stale chunk reference at %v", i)
+		}
+	}
+	for _, ck := range acPool.chunkPool.pool {
+		if ck.Cap != int64(chunkSz) {
+			t.Errorf("large chunk leaked into the normal pool: cap %v", ck.Cap)
+		}
+	}
+}
+
+func Test_DbgSizeHistogram(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	defer acPool.EnableDebugMode(false)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	for i := 0; i < 10; i++ {
+		ac.alloc(48, false) // bucket 64
+	}
+	ac.alloc(64, false) // bucket 64, the upper bound is inclusive
+	ac.alloc(65, false) // bucket 128
+	ac.alloc(1, false)  // bucket 1
+
+	h := ac.DbgSizeHistogram()
+	if h[64] != 11 || h[128] != 1 || h[1] != 1 {
+		t.Errorf("histogram: %v", h)
+	}
+
+	ac.Reset()
+	if h := ac.DbgSizeHistogram(); len(h) != 0 {
+		t.Errorf("histogram should clear on reset: %v", h)
+	}
+}
+
+func Test_SyncPrimitiveCheck(t *testing.T) {
+	type guarded struct {
+		mu sync.Mutex
+		n  int
+	}
+
+	// release mode: allowed, the user takes the lifecycle risk.
+	acPool.EnableDebugMode(false)
+	ac := acPool.Get()
+	New[guarded](ac)
+	ac.Release()
+
+	// debug mode: reported.
+	acPool.EnableDebugMode(true)
+	defer acPool.EnableDebugMode(false)
+	ac = acPool.Get()
+	defer ac.Release()
+
+	New[PbData](ac) // no sync fields, fine
+
+	defer func() {
+		if e := recover(); e == nil {
+			t.Errorf("sync primitive in arena type should be reported")
+		}
+	}()
+	New[guarded](ac)
+}
+
+func Test_MetricsSnapshot(t *testing.T) {
+	acPool.EnableDebugMode(false)
+	before := acPool.MetricsSnapshot()
+
+	ac := acPool.Get()
+	NewSlice[byte](ac, 0, acPool.chunkPool.ChunkSize*2) // a dedicated chunk
+	ac.Release()
+
+	after := acPool.MetricsSnapshot()
+	if after.ChunksMiss != before.ChunksMiss+1 {
+		t.Errorf("miss: %v -> %v", before.ChunksMiss, after.ChunksMiss)
+	}
+	if after.AllocBytes <= before.AllocBytes {
+		t.Errorf("alloc bytes should grow")
+	}
+	if after.ReservedBytes <= 0 || after.ChunkSize != acPool.chunkPool.ChunkSize {
+		t.Errorf("snapshot: %+v", after)
+	}
+
+	got := map[string]int64{}
+	acPool.ExportMetrics(func(name string, v int64) { got[name] = v })
+	if len(got) != 10 || got["lac_test_chunk_size"] != int64(acPool.chunkPool.ChunkSize) {
+		t.Errorf("export: %v", got)
+	}
+}
+
+func Test_WarnLargeObject(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	log := &logCapture{}
+	acPool.Logger = log
+	DbgWarnLargeObject = 1024
+	defer func() {
+		DbgWarnLargeObject = 0
+		acPool.Logger = nil
+	}()
+
+	ac := acPool.Get()
+	defer ac.Release()
+
+	type big struct {
+		data [2048]byte
+	}
+	_ = New[big](ac)
+	_ = NewSlice[int64](ac, 0, 1024)
+	_ = New[PbItem](ac) // small, no warning.
+
+	if len(log.msgs) != 2 {
+		t.Fatalf("expect 2 warnings, got %v", log.msgs)
+	}
+	if !strings.Contains(log.msgs[0], "big") || !strings.Contains(log.msgs[0], "2048") {
+		t.Errorf("warning: %v", log.msgs[0])
+	}
+}
+
+func Test_ResetCanaries(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	DbgResetCanaries = true
+	defer func() { DbgResetCanaries = false }()
+
+	ac := acPool.Get()
+	defer ac.Release()
+
+	_ = NewSlice[byte](ac, 0, 128)
+	stale := ac.chunks[0].Data
+
+	// 1st reset stamps the canaries; a clean 2nd reset verifies fine.
+	ac.Reset()
+	_ = New[PbItem](ac)
+	ac.Reset()
+
+	// a write through a stale pointer is caught at the following reset.
+	_ = New[PbItem](ac)
+	stale = ac.chunks[0].Data
+	ac.Reset()
+	*(*uint64)(stale) = 0x1234
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expect canary panic")
+			}
+		}()
+		ac.Reset()
+	}()
+}
+
+func Test_WithOverflow(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get().WithOverflow(4 * 1024)
+	defer ac.Release()
+
+	ov := ac.overflow
+	if ov == nil {
+		t.Fatalf("no overflow allocator")
+	}
+
+	d := New[PbData](ac)
+	for i := 0; i < 1024; i++ {
+		item := New[PbItem](ac)
+		item.Id = ac.Int(i)
+		d.Items = Append(ac, d.Items, item)
+	}
+
+	if used := atomic.LoadInt64(&ac.primaryUsed); used > 4*1024 {
+		t.Errorf("primary past budget: %v", used)
+	}
+	if ov.IsEmpty() {
+		t.Errorf("overflow tier untouched")
+	}
+	for i, item := range d.Items {
+		if *item.Id != i {
+			t.Errorf("item %v corrupted", i)
+		}
+	}
+
+	// cross-tier pointers are internal to the facade.
+	ac.CheckExternalPointers()
+
+	if s := ac.Stats(); !strings.Contains(s, "overflow") {
+		t.Errorf("stats: %v", s)
+	}
+
+	// the overflow tier is torn down with the facade.
+	ac.Reset()
+	if ac.overflow != nil || atomic.LoadInt64(&ac.primaryUsed) != 0 {
+		t.Errorf("overflow not torn down")
+	}
+}
+
+func Test_CheckGeneration(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	item := New[PbItem](ac)
+	if !ac.CheckGeneration(unsafe.Pointer(item)) {
+		t.Errorf("current-cycle pointer misreported as stale")
+	}
+
+	// hold the pointer across a reset.
+	ac.reset()
+	if ac.CheckGeneration(unsafe.Pointer(item)) {
+		t.Errorf("stale pointer not detected")
+	}
+
+	// the root scan flags it too.
+	type holder struct {
+		It *PbItem
+	}
+	h := &holder{It: item}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expect panic")
+			}
+		}()
+		ac.CheckStalePointers(h)
+	}()
+
+	// a current-generation graph passes.
+	h.It = New[PbItem](ac)
+	ac.CheckStalePointers(h)
+}
+
+func Test_DbgValidateSlice(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	s := NewSlice[int](ac, 4, 4)
+	DbgValidateSlice(ac, s)
+
+	// retained across a reset: the backing is from the dead generation.
+	ac.reset()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expect panic")
+			}
+		}()
+		DbgValidateSlice(ac, s)
+	}()
+
+	// a fresh slice of the new cycle passes, as do nil and native slices.
+	DbgValidateSlice(ac, NewSlice[int](ac, 4, 4))
+	DbgValidateSlice[int](ac, nil)
+	DbgValidateSlice(nil, make([]int, 4))
+}
@@ -0,0 +1,189 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+// Reflection-based factories for config/DTO structs.
+// these are opt-in and reflect-heavy, keep them away from hot paths.
+
+package lac
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"unsafe"
+)
+
+// NewWithDefaults allocates T from lac and applies `default:"..."` struct tags
+// to the exported pointer-scalar fields, pointing them at lac-allocated copies
+// of the parsed defaults. e.g.
+//
+//	type Config struct {
+//		Retry   *int    `default:"5"`
+//		Verbose *bool   `default:"true"`
+//		Name    *string `default:"svr"`
+//	}
+//
+// supported field types: *int, *int32, *int64, *uint32, *uint64,
+// *float32, *float64, *bool, *string.
+func NewWithDefaults[T any](ac *Allocator) (*T, error) {
+	r := New[T](ac)
+	v := reflect.ValueOf(r).Elem()
+	tp := v.Type()
+
+	for i := 0; i < tp.NumField(); i++ {
+		field := tp.Field(i)
+		tag, ok := field.Tag.Lookup("default")
+		if !ok {
+			continue
+		}
+		f := v.Field(i)
+		if !f.CanSet() || f.Kind() != reflect.Ptr {
+			return nil, fmt.Errorf("NewWithDefaults: unsupported field: %v.%v", tp.Name(), field.Name)
+		}
+
+		var val any
+		var err error
+		switch f.Type().Elem().Kind() {
+		case reflect.Int:
+			var n int
+			if n, err = strconv.Atoi(tag); err == nil {
+				val = ac.Int(n)
+			}
+		case reflect.Int32:
+			var n int64
+			if n, err = strconv.ParseInt(tag, 10, 32); err == nil {
+				val = ac.Int32(int32(n))
+			}
+		case reflect.Int64:
+			var n int64
+			if n, err = strconv.ParseInt(tag, 10, 64); err == nil {
+				val = ac.Int64(n)
+			}
+		case reflect.Uint32:
+			var n uint64
+			if n, err = strconv.ParseUint(tag, 10, 32); err == nil {
+				val = ac.Uint32(uint32(n))
+			}
+		case reflect.Uint64:
+			var n uint64
+			if n, err = strconv.ParseUint(tag, 10, 64); err == nil {
+				val = ac.Uint64(n)
+			}
+		case reflect.Float32:
+			var n float64
+			if n, err = strconv.ParseFloat(tag, 32); err == nil {
+				val = ac.Float32(float32(n))
+			}
+		case reflect.Float64:
+			var n float64
+			if n, err = strconv.ParseFloat(tag, 64); err == nil {
+				val = ac.Float64(n)
+			}
+		case reflect.Bool:
+			var b bool
+			if b, err = strconv.ParseBool(tag); err == nil {
+				val = ac.Bool(b)
+			}
+		case reflect.String:
+			val = ac.String(tag)
+		default:
+			return nil, fmt.Errorf("NewWithDefaults: unsupported field type: %v.%v", tp.Name(), field.Name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("NewWithDefaults: bad default for %v.%v: %w", tp.Name(), field.Name, err)
+		}
+		f.Set(reflect.ValueOf(val))
+	}
+	return r, nil
+}
+
+// NewFromMap allocates T from lac and fills its exported fields from m by
+// name, bridging decoded JSON/config maps into the typed arena world.
+// strings and slices are arena-allocated; decoded JSON numbers (float64)
+// are converted to the field's numeric kind. unknown keys are ignored,
+// type mismatches come back as errors.
+func NewFromMap[T any](ac *Allocator, m map[string]interface{}) (*T, error) {
+	r := New[T](ac)
+	v := reflect.ValueOf(r).Elem()
+	tp := v.Type()
+
+	for name, raw := range m {
+		field, ok := tp.FieldByName(name)
+		if !ok || field.Anonymous {
+			continue
+		}
+		f := v.FieldByIndex(field.Index)
+		if !f.CanSet() {
+			return nil, fmt.Errorf("NewFromMap: cannot set %v.%v", tp.Name(), name)
+		}
+		if raw == nil {
+			continue
+		}
+		if err := ac.setFromValue(f, reflect.ValueOf(raw)); err != nil {
+			return nil, fmt.Errorf("NewFromMap: field %v.%v: %w", tp.Name(), name, err)
+		}
+	}
+	return r, nil
+}
+
+func (ac *Allocator) setFromValue(f, rv reflect.Value) error {
+	ft := f.Type()
+	switch ft.Kind() {
+	case reflect.String:
+		if rv.Kind() != reflect.String {
+			return fmt.Errorf("expect string, got %v", rv.Type())
+		}
+		f.SetString(ac.NewString(rv.String()))
+		return nil
+
+	case reflect.Slice:
+		if rv.Kind() != reflect.Slice {
+			return fmt.Errorf("expect %v, got %v", ft, rv.Type())
+		}
+		n := rv.Len()
+		s := ac.reflectNewSlice(ft, n)
+		for i := 0; i < n; i++ {
+			ev := rv.Index(i)
+			// decoded JSON arrays come as []interface{}.
+			if ev.Kind() == reflect.Interface {
+				ev = ev.Elem()
+			}
+			if err := ac.setFromValue(s.Index(i), ev); err != nil {
+				return fmt.Errorf("elem %v: %w", i, err)
+			}
+		}
+		f.Set(s)
+		return nil
+
+	default:
+		if rv.Type() == ft {
+			f.Set(rv)
+			return nil
+		}
+		// decoded JSON numbers arrive as float64, convert between scalar kinds.
+		if !mayContainsPtr(ft.Kind()) && !mayContainsPtr(rv.Kind()) && rv.CanConvert(ft) {
+			f.Set(rv.Convert(ft))
+			return nil
+		}
+		return fmt.Errorf("expect %v, got %v", ft, rv.Type())
+	}
+}
+
+// reflectNewSlice is the reflection counterpart of NewSlice: a zeroed,
+// arena-backed slice of type t with len = cap = n.
+func (ac *Allocator) reflectNewSlice(t reflect.Type, n int) reflect.Value {
+	sv := reflect.New(t)
+	if n > 0 {
+		h := (*sliceHeader)(unsafe.Pointer(sv.Pointer()))
+		h.Data = ac.alloc(n*int(t.Elem().Size()), true)
+		h.Len = int64(n)
+		h.Cap = int64(n)
+	}
+	return sv.Elem()
+}
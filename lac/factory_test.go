@@ -0,0 +1,110 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+package lac
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func Test_NewWithDefaults(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	type Config struct {
+		Retry   *int     `default:"5"`
+		Ratio   *float64 `default:"0.5"`
+		Verbose *bool    `default:"true"`
+		Name    *string  `default:"svr"`
+		NoTag   *int
+	}
+
+	c, err := NewWithDefaults[Config](ac)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *c.Retry != 5 || *c.Ratio != 0.5 || !*c.Verbose || *c.Name != "svr" {
+		t.Errorf("defaults: %+v", c)
+	}
+	if c.NoTag != nil {
+		t.Errorf("untagged field touched")
+	}
+
+	// the defaults must be lac-resident.
+	if ac.checkPointerType(uintptr(unsafe.Pointer(c.Retry))) != pointerTypeLacInternal {
+		t.Errorf("default not in lac")
+	}
+
+	type Bad struct {
+		Retry *int `default:"oops"`
+	}
+	if _, err := NewWithDefaults[Bad](ac); err == nil {
+		t.Errorf("should report the bad default")
+	}
+}
+
+func Test_NewFromMap(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	type Config struct {
+		Name    string
+		Retry   int
+		Ratio   float64
+		Verbose bool
+		Tags    []string
+		Ports   []int
+		skip    int
+	}
+
+	c, err := NewFromMap[Config](ac, map[string]interface{}{
+		"Name":    "svr",
+		"Retry":   float64(5), // decoded JSON number
+		"Ratio":   0.5,
+		"Verbose": true,
+		"Tags":    []interface{}{"a", "b"},
+		"Ports":   []interface{}{float64(80), float64(443)},
+		"Unknown": "ignored",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "svr" || c.Retry != 5 || c.Ratio != 0.5 || !c.Verbose {
+		t.Errorf("scalars: %+v", c)
+	}
+	if len(c.Tags) != 2 || c.Tags[0] != "a" || c.Tags[1] != "b" {
+		t.Errorf("tags: %v", c.Tags)
+	}
+	if len(c.Ports) != 2 || c.Ports[0] != 80 || c.Ports[1] != 443 {
+		t.Errorf("ports: %v", c.Ports)
+	}
+	if c.skip != 0 {
+		t.Errorf("unexported touched")
+	}
+
+	// string bytes and slice backings must be lac-resident.
+	if ac.checkPointerType(uintptr((*stringHeader)(unsafe.Pointer(&c.Name)).Data)) != pointerTypeLacInternal {
+		t.Errorf("name not in lac")
+	}
+	if ac.checkPointerType(uintptr((*sliceHeader)(unsafe.Pointer(&c.Tags)).Data)) != pointerTypeLacInternal {
+		t.Errorf("tags not in lac")
+	}
+
+	if _, err = NewFromMap[Config](ac, map[string]interface{}{"Retry": "oops"}); err == nil {
+		t.Errorf("mismatch not reported")
+	}
+	if _, err = NewFromMap[Config](ac, map[string]interface{}{"Tags": []interface{}{1}}); err == nil {
+		t.Errorf("elem mismatch not reported")
+	}
+	if _, err = NewFromMap[Config](ac, map[string]interface{}{"skip": 1}); err == nil {
+		t.Errorf("unexported not reported")
+	}
+}
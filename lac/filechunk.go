@@ -0,0 +1,75 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+// Out-of-core support: allocations larger than ChunkSize can be spilled to
+// memory-mapped temp files instead of the heap, so huge NewSlice calls don't
+// OOM. pointers into the mappings are ordinary process addresses.
+//
+// NOTE: mmap-backed chunks need mmap/munmap (Linux/Darwin); elsewhere the
+// allocator silently falls back to heap chunks.
+
+package lac
+
+import "unsafe"
+
+// LargeChunkAllocator supplies dedicated chunks for allocations larger than
+// the pool's ChunkSize. set it via AllocatorPool.LargeAlloc.
+// AllocChunk may return nil to fall back to a heap chunk; FreeChunk must
+// ignore chunks it does not own.
+type LargeChunkAllocator interface {
+	AllocChunk(size int) *sliceHeader
+	FreeChunk(ck *sliceHeader)
+}
+
+// FileBackedChunkAllocator mmaps one unlinked temp file per large chunk.
+// the file space is reclaimed by the OS when the chunk is freed, so datasets
+// larger than RAM page in and out instead of growing the heap.
+type FileBackedChunkAllocator struct {
+	// Dir holds the temp files, empty means os.TempDir().
+	Dir string
+
+	lock     spinLock
+	mappings map[unsafe.Pointer]func()
+}
+
+func NewFileBackedChunkAllocator(dir string) *FileBackedChunkAllocator {
+	return &FileBackedChunkAllocator{
+		Dir:      dir,
+		mappings: map[unsafe.Pointer]func(){},
+	}
+}
+
+func (a *FileBackedChunkAllocator) AllocChunk(size int) *sliceHeader {
+	b, unmap, err := mmapFileChunk(a.Dir, size)
+	if err != nil {
+		return nil
+	}
+	h := &sliceHeader{Data: unsafe.Pointer(&b[0]), Cap: int64(size)}
+	a.lock.Lock()
+	a.mappings[h.Data] = unmap
+	a.lock.Unlock()
+	return h
+}
+
+func (a *FileBackedChunkAllocator) FreeChunk(ck *sliceHeader) {
+	a.lock.Lock()
+	unmap := a.mappings[ck.Data]
+	delete(a.mappings, ck.Data)
+	a.lock.Unlock()
+	if unmap != nil {
+		unmap()
+	}
+}
+
+// Mappings reports the number of live file mappings, useful for leak checks.
+func (a *FileBackedChunkAllocator) Mappings() int {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return len(a.mappings)
+}
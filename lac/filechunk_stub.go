@@ -0,0 +1,20 @@
+//go:build !linux && !darwin
+
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+package lac
+
+import "errors"
+
+// no mmap here: every AllocChunk fails, so large allocations fall back to
+// ordinary heap chunks.
+func mmapFileChunk(dir string, size int) ([]byte, func(), error) {
+	return nil, nil, errors.New("lac: file-backed chunks unsupported on this platform")
+}
@@ -0,0 +1,56 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+package lac
+
+import (
+	"testing"
+)
+
+func Test_FileBackedChunks(t *testing.T) {
+	if _, unmap, err := mmapFileChunk(t.TempDir(), 1<<16); err != nil {
+		t.Skip("mmap unavailable:", err)
+	} else {
+		unmap()
+	}
+	fa := NewFileBackedChunkAllocator(t.TempDir())
+
+	acPool.EnableDebugMode(false)
+	acPool.LargeAlloc = fa
+	defer func() { acPool.LargeAlloc = nil }()
+
+	ac := acPool.Get()
+
+	// much larger than ChunkSize: served by a file mapping.
+	n := acPool.chunkPool.ChunkSize * 4
+	s := NewSlice[byte](ac, n, n)
+	if fa.Mappings() == 0 {
+		t.Fatalf("large alloc not file-backed")
+	}
+	for i := range s {
+		s[i] = byte(i)
+	}
+	for i := range s {
+		if s[i] != byte(i) {
+			t.Fatalf("readback at %v", i)
+		}
+	}
+
+	// small allocations keep using pooled chunks.
+	before := fa.Mappings()
+	_ = New[PbItem](ac)
+	if fa.Mappings() != before {
+		t.Errorf("small alloc file-backed")
+	}
+
+	ac.Release()
+	if fa.Mappings() != 0 {
+		t.Errorf("mappings leaked: %v", fa.Mappings())
+	}
+}
@@ -0,0 +1,39 @@
+//go:build linux || darwin
+
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+package lac
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapFileChunk maps size bytes of a fresh temp file.
+// the file is unlinked right away, the mapping alone keeps the space alive
+// until the returned unmap runs.
+func mmapFileChunk(dir string, size int) (b []byte, unmap func(), err error) {
+	f, err := os.CreateTemp(dir, "lac-chunk-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	defer os.Remove(f.Name())
+
+	if err = f.Truncate(int64(size)); err != nil {
+		return nil, nil, err
+	}
+	b, err = syscall.Mmap(int(f.Fd()), 0, size,
+		syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, func() { _ = syscall.Munmap(b) }, nil
+}
@@ -0,0 +1,21 @@
+//go:build !linux && !darwin
+
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+package lac
+
+import "unsafe"
+
+// no mmap/mprotect here: DbgGuardPages silently falls back to chunk allocation.
+const guardPagesSupported = false
+
+func guardAlloc(need int) (ptr unsafe.Pointer, mapping []byte) { return nil, nil }
+
+func guardFree(mapping []byte) {}
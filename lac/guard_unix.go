@@ -0,0 +1,49 @@
+//go:build linux || darwin
+
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+package lac
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const guardPagesSupported = true
+
+var pageSize = syscall.Getpagesize()
+
+// guardAlloc maps a dedicated region for one object and protects the page
+// right after it, so writing past the object faults deterministically.
+// the object is placed at the tail of the accessible part to keep the guard
+// page adjacent. returns nil on mapping failure.
+func guardAlloc(need int) (ptr unsafe.Pointer, mapping []byte) {
+	aligned := alignSize(need)
+	dataSz := (aligned + pageSize - 1) / pageSize * pageSize
+	if dataSz == 0 {
+		dataSz = pageSize
+	}
+
+	b, err := syscall.Mmap(-1, 0, dataSz+pageSize,
+		syscall.PROT_READ|syscall.PROT_WRITE,
+		syscall.MAP_PRIVATE|syscall.MAP_ANON)
+	if err != nil {
+		return nil, nil
+	}
+	if err = syscall.Mprotect(b[dataSz:], syscall.PROT_NONE); err != nil {
+		_ = syscall.Munmap(b)
+		return nil, nil
+	}
+	return unsafe.Pointer(&b[dataSz-aligned]), b
+}
+
+func guardFree(mapping []byte) {
+	_ = syscall.Munmap(mapping)
+}
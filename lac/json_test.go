@@ -0,0 +1,63 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+package lac
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"unsafe"
+)
+
+func Test_Buffer(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	b := NewBuffer(ac, 4)
+	for i := 0; i < 10; i++ {
+		if _, err := b.Write([]byte("ab")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if b.Len() != 20 || !bytes.Equal(b.Bytes(), bytes.Repeat([]byte("ab"), 10)) {
+		t.Errorf("buffer: %q", b.Bytes())
+	}
+}
+
+func Test_EncodeJSON(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	d := New[PbData](ac)
+	d.Age = ac.Int(30)
+	item := New[PbItem](ac)
+	item.Id = ac.Int(1)
+	item.Name = ac.String("a")
+	d.Items = Append(ac, d.Items, item)
+
+	out, err := ac.EncodeJSON(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the output must live in the arena.
+	h := (*sliceHeader)(unsafe.Pointer(&out))
+	if ac.checkPointerType(uintptr(h.Data)) != pointerTypeLacInternal {
+		t.Errorf("output not arena-backed")
+	}
+
+	var back PbData
+	if err = json.Unmarshal(out, &back); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	if *back.Age != 30 || len(back.Items) != 1 || *back.Items[0].Id != 1 || *back.Items[0].Name != "a" {
+		t.Errorf("round trip: %s", out)
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+// zero-alloc regression suite.
+// the zero-alloc guarantee of these APIs rests on the compiler's escape
+// analysis keeping arguments and internal state off the heap; a toolchain
+// update can silently break that without failing any functional test. every
+// public API claiming zero-alloc is pinned here so such a regression fails
+// in CI instead of in a user's profile.
+
+package lac
+
+import (
+	"runtime"
+	"testing"
+	"unsafe"
+)
+
+func Test_ZeroAllocRegression(t *testing.T) {
+	// debug tracking allocates natively by design, it is out of scope here.
+	acPool.EnableDebugMode(false)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	// warm the reflect caches and the first chunk outside the measured
+	// sections: the first call of a generic API may populate a type cache.
+	_ = New[PbItem](ac)
+	_ = NewFrom(ac, &PbItem{})
+	_ = NewSlice[int](ac, 0, 4)
+	_ = NewEnum(ac, EnumVal1)
+	// the first Free of a size class grows the native free-list slice.
+	b := ac.AllocRecyclable(64)
+	_ = CastSlice[uint32](ac, b)
+	ac.Free(b)
+
+	noMalloc(func() {
+		_ = New[PbItem](ac)
+		_ = New[PbData](ac)
+	})
+
+	noMalloc(func() {
+		_ = NewFrom(ac, &PbItem{})
+	})
+
+	noMalloc(func() {
+		// scalar helpers.
+		_ = ac.Bool(true)
+		_ = ac.Int(1)
+		_ = ac.Int32(1)
+		_ = ac.Uint32(1)
+		_ = ac.Int64(1)
+		_ = ac.Uint64(1)
+		_ = ac.Float32(1)
+		_ = ac.Float64(1)
+		_ = ac.String("s")
+		_ = ac.NewString("s")
+	})
+
+	noMalloc(func() {
+		s := NewSlice[int](ac, 0, 4)
+		// both the fast path and arena-backed growth.
+		for i := 0; i < 100; i++ {
+			s = Append(ac, s, i)
+		}
+		s = GrowSlice(ac, s, 100)
+		ExtendZero(ac, &s, 50)
+		runtime.KeepAlive(s)
+	})
+
+	noMalloc(func() {
+		_ = NewEnum(ac, EnumVal2)
+	})
+
+	noMalloc(func() {
+		b := ac.AllocRecyclable(64)
+		_ = CastSlice[uint32](ac, b)[0]
+		ac.Free(b)
+	})
+
+	// the appender closures themselves escape, create them outside.
+	appendFn, done := NewAppender[int](ac, 4)
+	noMalloc(func() {
+		for i := 0; i < 100; i++ {
+			appendFn(i)
+		}
+	})
+	if len(done()) != 100 {
+		t.Errorf("appender")
+	}
+
+	noMalloc(func() {
+		p := New[PbItem](ac)
+		sz := AllocSizeOf[PbItem]()
+		ac.FreeLast(unsafe.Pointer(p), sz)
+	})
+}
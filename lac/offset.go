@@ -0,0 +1,76 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+// Offset addressing.
+// chunk-relative offsets survive serialization and mmap relocation, while raw
+// pointers do not. these helpers convert between the two worlds so a graph can
+// be stored by offset and reconstructed as typed pointers later.
+
+package lac
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// PtrToOffset converts a lac pointer into a (chunk index, offset) pair.
+// returns ok=false for pointers not owned by this allocator.
+func (ac *Allocator) PtrToOffset(p unsafe.Pointer) (chunk, off int, ok bool) {
+	addr := uintptr(p)
+	for i, h := range ac.chunks {
+		if addr >= uintptr(h.Data) && addr < uintptr(h.Data)+uintptr(h.Cap) {
+			return i, int(addr - uintptr(h.Data)), true
+		}
+	}
+	return 0, 0, false
+}
+
+// OffsetToPtr is the reverse of PtrToOffset.
+func (ac *Allocator) OffsetToPtr(chunk, off int) unsafe.Pointer {
+	h := ac.chunks[chunk]
+	return unsafe.Add(h.Data, off)
+}
+
+// NewGraph allocates n contiguous zeroed T's as one block for graph nodes:
+// wire node pointers to &g[i] and every link stays inside the block, which
+// the pointer checker accepts as lac-internal. since the nodes share one
+// allocation, the whole graph serializes as chunk-relative offsets via
+// PtrToOffset and reconstructs with TypedAt.
+func NewGraph[T any](ac *Allocator, n int) []T {
+	if n <= 0 {
+		return nil
+	}
+	if ac == nil {
+		return make([]T, n)
+	}
+	g := NewSlice[T](ac, n, n)
+	// NewSlice only zeroes pointer-carrying elements; graph nodes are
+	// documented zeroed for any T.
+	var t *T
+	if !mayContainsPtr(reflect.TypeOf(t).Elem().Kind()) {
+		memclrNoHeapPointers(unsafe.Pointer(&g[0]), uintptr(n)*unsafe.Sizeof(*t))
+	}
+	return g
+}
+
+// TypedAt returns a typed view at a chunk-relative offset, the typed
+// counterpart of OffsetToPtr, needed to reconstruct typed graphs after
+// deserializing or mmapping an arena.
+// in debug mode it asserts the whole object lies within the chunk.
+func TypedAt[T any](ac *Allocator, chunk, off int) *T {
+	h := ac.chunks[chunk]
+	if ac.acPool.debugMode {
+		var t *T
+		if off < 0 || int64(off)+int64(unsafe.Sizeof(*t)) > h.Cap {
+			errorf(ac.acPool, "TypedAt: out of chunk: off %v, size %v, chunk cap %v",
+				off, unsafe.Sizeof(*t), h.Cap)
+		}
+	}
+	return (*T)(unsafe.Add(h.Data, off))
+}
@@ -0,0 +1,104 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+package lac
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func Test_TypedAt(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	item := New[PbItem](ac)
+	item.Id = ac.Int(11)
+
+	chunk, off, ok := ac.PtrToOffset(unsafe.Pointer(item))
+	if !ok {
+		t.Errorf("lac pointer not resolved")
+	}
+
+	back := TypedAt[PbItem](ac, chunk, off)
+	if back != item || *back.Id != 11 {
+		t.Errorf("typed view mismatch")
+	}
+
+	if ac.OffsetToPtr(chunk, off) != unsafe.Pointer(item) {
+		t.Errorf("raw view mismatch")
+	}
+
+	if _, _, ok := ac.PtrToOffset(unsafe.Pointer(new(int))); ok {
+		t.Errorf("heap pointer should not resolve")
+	}
+}
+
+func Test_TypedAtOutOfChunk(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	defer func() {
+		if err := recover(); err == nil {
+			t.Errorf("should panic")
+		}
+	}()
+
+	_ = New[PbItem](ac)
+	_ = TypedAt[PbItem](ac, 0, int(ac.chunks[0].Cap)-1)
+}
+
+func Test_NewGraph(t *testing.T) {
+	acPool.EnableDebugMode(true)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	type node struct {
+		id          int
+		left, right *node
+	}
+
+	g := NewGraph[node](ac, 8)
+	for i := range g {
+		if g[i].left != nil || g[i].right != nil {
+			t.Fatalf("node %v not zeroed", i)
+		}
+		g[i].id = i
+		g[i].left = &g[(i+1)%len(g)]
+		g[i].right = &g[(i+3)%len(g)]
+	}
+	// intra-block links are lac-internal.
+	ac.CheckExternalPointers()
+
+	// one contiguous block: all nodes resolve into the same chunk.
+	c0, off0, ok := ac.PtrToOffset(unsafe.Pointer(&g[0]))
+	if !ok {
+		t.Fatalf("node 0 not in lac")
+	}
+	for i := 1; i < len(g); i++ {
+		c, off, ok := ac.PtrToOffset(unsafe.Pointer(&g[i]))
+		if !ok || c != c0 || off != off0+i*int(unsafe.Sizeof(node{})) {
+			t.Errorf("node %v not contiguous", i)
+		}
+	}
+	for i, nd := range g {
+		if nd.left.id != (i+1)%len(g) || nd.right.id != (i+3)%len(g) {
+			t.Errorf("node %v wired wrong", i)
+		}
+	}
+
+	if NewGraph[node](ac, 0) != nil {
+		t.Errorf("empty graph")
+	}
+	if len(NewGraph[node](nil, 3)) != 3 {
+		t.Errorf("native graph")
+	}
+}
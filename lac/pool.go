@@ -18,11 +18,13 @@ package lac
 
 type Pool[T any] struct {
 	Logger
-	m      spinLock
-	New    func() T
-	pool   []T
-	Cap    int
-	newCnt int
+	m       spinLock
+	New     func() T
+	pool    []T
+	Cap     int
+	newCnt  int
+	getCnt  int
+	missCnt int
 	// the max count of call to New function.
 	MaxNew int
 	Name   string
@@ -37,7 +39,9 @@ func (p *Pool[T]) Get() T {
 	p.m.Lock()
 	defer p.m.Unlock()
 
+	p.getCnt++
 	if len(p.pool) == 0 {
+		p.missCnt++
 		return p.doNew()
 	}
 
@@ -77,6 +81,18 @@ func (p *Pool[T]) Put(v T) bool {
 	}
 }
 
+// HitRate reports the lifetime fraction of Get calls satisfied from the pool
+// instead of the New function.
+func (p *Pool[T]) HitRate() float64 {
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	if p.getCnt == 0 {
+		return 0
+	}
+	return float64(p.getCnt-p.missCnt) / float64(p.getCnt)
+}
+
 func (p *Pool[T]) Clear() {
 	p.m.Lock()
 	defer p.m.Unlock()
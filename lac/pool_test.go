@@ -38,6 +38,27 @@ func Test_PoolMemLeak(t *testing.T) {
 	}
 }
 
+func Test_PoolHitRate(t *testing.T) {
+	p := Pool[int]{
+		New: func() int { return 0 },
+	}
+	p.Get() // miss
+	p.Put(1)
+	p.Get() // hit
+	if r := p.HitRate(); r != 0.5 {
+		t.Errorf("hit rate: %v", r)
+	}
+
+	pool := NewAllocatorPool("hit", nil, 10, 1024, 1, 2)
+	ac := pool.Get()
+	_ = NewSlice[byte](ac, 0, 1000) // from the reserved chunk
+	_ = NewSlice[byte](ac, 0, 1000) // fresh
+	ac.Release()
+	if r := pool.PoolHitRate(); r != 0.5 {
+		t.Errorf("chunk hit rate: %v", r)
+	}
+}
+
 func Test_PoolExceedMaxNew(t *testing.T) {
 	p := Pool[int]{
 		New:              func() int { return 0 },
@@ -0,0 +1,15 @@
+//go:build !race
+
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+package lac
+
+// raceEnabled reports whether the build runs under the race detector.
+const raceEnabled = false
@@ -0,0 +1,97 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+package lac
+
+import (
+	"math/bits"
+	"unsafe"
+)
+
+// For []byte-heavy codecs that allocate and free many same-size buffers within
+// one cycle, pure bump allocation can't reuse freed memory until reset.
+// AllocRecyclable and Free add a small size-classed free-list layer on top of
+// the bump allocator, so freed buffers are reused within the cycle and the
+// peak footprint stays low.
+
+const (
+	// size classes are powers of two from 32B to 64KB.
+	minRecycleShift   = 5
+	maxRecycleShift   = 16
+	numRecycleClasses = maxRecycleShift - minRecycleShift + 1
+)
+
+// recycleClass returns the class index and the rounded size,
+// or (-1, size) if the size is not recyclable.
+func recycleClass(size int) (int, int) {
+	if size <= 0 || size > 1<<maxRecycleShift {
+		return -1, size
+	}
+	shift := minRecycleShift
+	if size > 1<<minRecycleShift {
+		shift = bits.Len(uint(size - 1))
+	}
+	return shift - minRecycleShift, 1 << shift
+}
+
+// AllocRecyclable allocates a byte buffer whose backing can be reused within
+// the current cycle via Free. the capacity is rounded up to a power-of-two
+// size class; buffers beyond the largest class fall back to plain allocation.
+func (ac *Allocator) AllocRecyclable(size int) []byte {
+	if ac == nil {
+		return make([]byte, size)
+	}
+
+	class, rounded := recycleClass(size)
+	if class < 0 {
+		return NewSlice[byte](ac, size, size)
+	}
+
+	var ptr unsafe.Pointer
+	ac.freeLock.Lock()
+	if l := ac.freeLists[class]; len(l) > 0 {
+		ptr = l[len(l)-1]
+		ac.freeLists[class] = l[:len(l)-1]
+	}
+	ac.freeLock.Unlock()
+
+	if ptr == nil {
+		ptr = ac.alloc(rounded, false)
+	}
+
+	var r []byte
+	h := (*sliceHeader)(unsafe.Pointer(&r))
+	h.Data = ptr
+	h.Len = int64(size)
+	h.Cap = int64(rounded)
+	return r
+}
+
+// Free returns a buffer from AllocRecyclable to the in-cycle free list.
+// provenance cannot be checked: any slice whose cap matches a size class is
+// accepted and its backing handed out again, so passing a buffer not
+// obtained from AllocRecyclable is undefined behavior. only caps matching no
+// class (never produced by AllocRecyclable) are ignored.
+// the buffer must not be used after Free.
+func (ac *Allocator) Free(b []byte) {
+	if ac == nil || cap(b) == 0 {
+		return
+	}
+
+	class, rounded := recycleClass(cap(b))
+	if class < 0 || rounded != cap(b) {
+		// not a size-classed buffer, leave it to the cycle reset.
+		return
+	}
+
+	h := (*sliceHeader)(unsafe.Pointer(&b))
+	ac.freeLock.Lock()
+	ac.freeLists[class] = append(ac.freeLists[class], h.Data)
+	ac.freeLock.Unlock()
+}
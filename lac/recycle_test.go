@@ -0,0 +1,94 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+package lac
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func Test_AllocRecyclable(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	b := ac.AllocRecyclable(100)
+	if len(b) != 100 || cap(b) != 128 {
+		t.Errorf("len:%v cap:%v", len(b), cap(b))
+	}
+	for i := range b {
+		b[i] = byte(i)
+	}
+	base := (*sliceHeader)(unsafe.Pointer(&b)).Data
+
+	// a freed buffer of the same class must be reused.
+	ac.Free(b)
+	b2 := ac.AllocRecyclable(128)
+	if (*sliceHeader)(unsafe.Pointer(&b2)).Data != base {
+		t.Errorf("should recycle the freed buffer")
+	}
+
+	// different class takes a fresh buffer.
+	b3 := ac.AllocRecyclable(1000)
+	if cap(b3) != 1024 {
+		t.Errorf("cap:%v", cap(b3))
+	}
+	if (*sliceHeader)(unsafe.Pointer(&b3)).Data == base {
+		t.Errorf("wrong class recycled")
+	}
+
+	// interleaved alloc/free/realloc keeps data intact.
+	bufs := make([][]byte, 0, 16)
+	for i := 0; i < 16; i++ {
+		bb := ac.AllocRecyclable(64)
+		for j := range bb {
+			bb[j] = byte(i)
+		}
+		bufs = append(bufs, bb)
+	}
+	for i := 0; i < 16; i += 2 {
+		ac.Free(bufs[i])
+	}
+	for i := 0; i < 8; i++ {
+		bb := ac.AllocRecyclable(64)
+		for j := range bb {
+			bb[j] = 0xee
+		}
+	}
+	for i := 1; i < 16; i += 2 {
+		for _, v := range bufs[i] {
+			if v != byte(i) {
+				t.Errorf("live buffer corrupted")
+			}
+		}
+	}
+
+	// beyond the largest class falls back to plain allocation.
+	big := ac.AllocRecyclable(1 << 20)
+	if len(big) != 1<<20 {
+		t.Fail()
+	}
+
+	// nil ac falls back to the native heap.
+	n := (*Allocator)(nil).AllocRecyclable(10)
+	if len(n) != 10 {
+		t.Fail()
+	}
+}
+
+func Benchmark_AllocRecyclable(b *testing.B) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf := ac.AllocRecyclable(512)
+		ac.Free(buf)
+	}
+}
@@ -0,0 +1,258 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+// Arena checkpointing.
+// Save writes an allocator's chunks plus a registered root object as a
+// self-describing gob stream, with every intra-arena pointer recorded as a
+// (chunk, offset) fixup; Load rebuilds the chunks and patches the pointers
+// back in. only graphs wired entirely through lac-internal pointers are
+// supported: externals (maps, interfaces, funcs, attached heap objects) do
+// not survive a process boundary.
+
+package lac
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"unsafe"
+)
+
+// acRef addresses a location inside an arena, see PtrToOffset.
+type acRef struct {
+	Chunk, Off int
+}
+
+// acFixup records a pointer word at Loc whose value must be rewritten to the
+// address of Target after the chunks are rebuilt.
+type acFixup struct {
+	Loc, Target acRef
+}
+
+// acImage is the on-disk form of an arena.
+type acImage struct {
+	Chunks [][]byte
+	Fixups []acFixup
+	Root   acRef
+}
+
+// SetRoot registers the entry object of the arena graph. Save refuses to run
+// without one: the root drives the pointer walk, anything unreachable from it
+// is stored as raw bytes without fixups. returns root for chaining.
+func SetRoot[T any](ac *Allocator, root *T) *T {
+	if ac == nil {
+		return root
+	}
+	ac.root = unsafe.Pointer(root)
+	ac.rootType = reflect.TypeOf(root)
+	return root
+}
+
+// Save checkpoints the arena to w: chunk bytes, the pointer fixup table
+// gathered by walking the graph from the SetRoot root, and the root's own
+// location. the walk mirrors the debug checker's: structs, arrays, slices,
+// strings and pointers are followed; a pointer leaving the arena fails the
+// save instead of writing an address that is meaningless on load.
+func Save(ac *Allocator, w io.Writer) error {
+	if ac == nil {
+		return fmt.Errorf("lac: cannot save a native allocator")
+	}
+	if ac.root == nil {
+		return fmt.Errorf("lac: no root registered, call SetRoot before Save")
+	}
+
+	img := acImage{Chunks: make([][]byte, len(ac.chunks))}
+	for i, h := range ac.chunks {
+		img.Chunks[i] = unsafe.Slice((*byte)(h.Data), h.Len)
+	}
+
+	rc, ro, ok := ac.PtrToOffset(ac.root)
+	if !ok {
+		return fmt.Errorf("lac: root is not owned by this allocator")
+	}
+	img.Root = acRef{rc, ro}
+
+	ctx := &saveCtx{ac: ac, visited: map[uintptr]struct{}{}}
+	if err := ctx.walk(ac.root, ac.rootType.Elem()); err != nil {
+		return err
+	}
+	img.Fixups = ctx.fixups
+
+	return gob.NewEncoder(w).Encode(&img)
+}
+
+type saveCtx struct {
+	ac      *Allocator
+	visited map[uintptr]struct{}
+	fixups  []acFixup
+}
+
+// fixup records the pointer word at loc targeting tgt. both must be internal.
+func (c *saveCtx) fixup(loc, tgt unsafe.Pointer) error {
+	lc, lo, ok := c.ac.PtrToOffset(loc)
+	if !ok {
+		return fmt.Errorf("lac: Save: pointer word at %p lies outside the arena", loc)
+	}
+	tc, to, ok := c.ac.PtrToOffset(tgt)
+	if !ok {
+		return fmt.Errorf("lac: Save: external pointer %p, only internal graphs can be saved", tgt)
+	}
+	c.fixups = append(c.fixups, acFixup{acRef{lc, lo}, acRef{tc, to}})
+	return nil
+}
+
+// walk visits the object of type t at p and collects its pointer words.
+func (c *saveCtx) walk(p unsafe.Pointer, t reflect.Type) error {
+	switch t.Kind() {
+	case reflect.Ptr:
+		tgt := *(*unsafe.Pointer)(p)
+		if tgt == nil {
+			return nil
+		}
+		if err := c.fixup(p, tgt); err != nil {
+			return err
+		}
+		// mark before recursing, otherwise a cyclic graph recurses forever.
+		if _, ok := c.visited[uintptr(tgt)]; ok {
+			return nil
+		}
+		c.visited[uintptr(tgt)] = struct{}{}
+		return c.walk(tgt, t.Elem())
+
+	case reflect.String:
+		h := (*stringHeader)(p)
+		if h.Data == nil {
+			return nil
+		}
+		return c.fixup(p, h.Data)
+
+	case reflect.Slice:
+		h := (*sliceHeader)(p)
+		if h.Data == nil {
+			return nil
+		}
+		if err := c.fixup(p, h.Data); err != nil {
+			return err
+		}
+		elem := t.Elem()
+		if !mayContainsPtr(elem.Kind()) {
+			return nil
+		}
+		sz := int(elem.Size())
+		for i := 0; i < int(h.Len); i++ {
+			if err := c.walk(unsafe.Add(h.Data, i*sz), elem); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !mayContainsPtr(f.Type.Kind()) {
+				continue
+			}
+			if err := c.walk(unsafe.Add(p, f.Offset), f.Type); err != nil {
+				return fmt.Errorf("%v.%v: %w", t.Name(), f.Name, err)
+			}
+		}
+		return nil
+
+	case reflect.Array:
+		elem := t.Elem()
+		if !mayContainsPtr(elem.Kind()) {
+			return nil
+		}
+		sz := int(elem.Size())
+		for i := 0; i < t.Len(); i++ {
+			if err := c.walk(unsafe.Add(p, i*sz), elem); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Map, reflect.Interface, reflect.Func, reflect.Chan:
+		return fmt.Errorf("lac: Save does not support %v fields, externals are out of scope", t.Kind())
+	}
+	return nil
+}
+
+// loadedAcPool backs Load allocators. like the deterministic pool its chunk
+// pool never serves chunks (loaded ones are rebuilt at their saved sizes), it
+// only supplies the pool identity for release and diagnostics.
+var loadedAcPool = NewAllocatorPool("loaded", nil, 64, 64*1024, 0, 0)
+
+// Load is the reverse of Save: it rebuilds the chunks from r, applies the
+// pointer fixups and returns a fresh allocator owning them together with the
+// root pointer, which the caller casts back to its concrete type. Release
+// works as usual; further allocations go into new chunks.
+func Load(r io.Reader) (*Allocator, unsafe.Pointer, error) {
+	var img acImage
+	if err := gob.NewDecoder(r).Decode(&img); err != nil {
+		return nil, nil, err
+	}
+
+	ac := &Allocator{
+		acPool:    loadedAcPool,
+		chunkPool: loadedAcPool.chunkPool,
+
+		externalPtr:    newWeakUniqQueue(32, eq[unsafe.Pointer]),
+		externalSlice:  newWeakUniqQueue(32, eq[unsafe.Pointer]),
+		externalString: newWeakUniqQueue(32, eq[unsafe.Pointer]),
+		externalMap:    newWeakUniqQueue(32, anyEq),
+		externalFunc:   newWeakUniqQueue(32, interfaceEqual),
+
+		dbgScanObjs: newWeakUniqQueue(math.MaxInt, anyEq),
+	}
+	ac.refCnt.Store(1)
+	loadedAcPool.Stats.TotalCreatedAc.Add(1)
+
+	for _, b := range img.Chunks {
+		t := make(chunk, 0, len(b))
+		globalReserved.Add(int64(len(b)))
+		h := (*sliceHeader)(unsafe.Pointer(&t))
+		copy(unsafe.Slice((*byte)(h.Data), len(b)), b)
+		h.Len = int64(len(b))
+		ac.chunks = append(ac.chunks, h)
+		ac.curChunk = unsafe.Pointer(h)
+	}
+
+	at := func(ref acRef, size int) (unsafe.Pointer, error) {
+		if ref.Chunk < 0 || ref.Chunk >= len(ac.chunks) {
+			return nil, fmt.Errorf("lac: Load: chunk %v out of range", ref.Chunk)
+		}
+		h := ac.chunks[ref.Chunk]
+		if ref.Off < 0 || int64(ref.Off)+int64(size) > h.Len {
+			return nil, fmt.Errorf("lac: Load: offset %v out of chunk %v", ref.Off, ref.Chunk)
+		}
+		return unsafe.Add(h.Data, ref.Off), nil
+	}
+
+	ptrSize := int(unsafe.Sizeof(unsafe.Pointer(nil)))
+	for _, f := range img.Fixups {
+		loc, err := at(f.Loc, ptrSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		tgt, err := at(f.Target, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		*(*unsafe.Pointer)(loc) = tgt
+	}
+
+	root, err := at(img.Root, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	ac.root = root
+	return ac, root, nil
+}
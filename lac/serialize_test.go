@@ -0,0 +1,102 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+package lac
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func Test_SaveLoadRoundTrip(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	d := SetRoot(ac, New[PbData](ac))
+	d.Age = ac.Int(11)
+	n := 3
+	for i := 0; i < n; i++ {
+		item := New[PbItem](ac)
+		item.Id = ac.Int(i + 1)
+		item.Active = ac.Bool(true)
+		item.Price = ac.Int(100 + i)
+		item.Name = ac.String(fmt.Sprintf("name%v", i))
+		d.Items = Append(ac, d.Items, item)
+	}
+	d.InUse = d.Items[1]
+
+	var buf bytes.Buffer
+	if err := Save(ac, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	ac2, root, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ac2.Release()
+
+	d2 := (*PbData)(root)
+	if *d2.Age != 11 {
+		t.Errorf("age")
+	}
+	if len(d2.Items) != n {
+		t.Fatalf("items: %v", len(d2.Items))
+	}
+	for i := 0; i < n; i++ {
+		if *d2.Items[i].Id != i+1 {
+			t.Errorf("item.id")
+		}
+		if !*d2.Items[i].Active {
+			t.Errorf("item.active")
+		}
+		if *d2.Items[i].Price != i+100 {
+			t.Errorf("item.price")
+		}
+		if *d2.Items[i].Name != fmt.Sprintf("name%v", i) {
+			t.Errorf("item.name")
+		}
+	}
+	// shared pointers stay shared after the round trip.
+	if d2.InUse != d2.Items[1] {
+		t.Errorf("InUse not aliased to Items[1]")
+	}
+
+	// the loaded arena allocates as usual.
+	d2.Items = Append(ac2, d2.Items, New[PbItem](ac2))
+	if len(d2.Items) != n+1 {
+		t.Errorf("append on loaded arena")
+	}
+}
+
+func Test_SaveRejectsExternals(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	type withHeapPtr struct {
+		P *int
+	}
+	o := SetRoot(ac, New[withHeapPtr](ac))
+	o.P = new(int)
+
+	var buf bytes.Buffer
+	if err := Save(ac, &buf); err == nil {
+		t.Errorf("expect an error on an external pointer")
+	}
+	// keep the release-time checker quiet when debug mode is on.
+	o.P = nil
+
+	// no root registered.
+	ac2 := acPool.Get()
+	defer ac2.Release()
+	if err := Save(ac2, &buf); err == nil {
+		t.Errorf("expect an error without a root")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"fmt"
 	"reflect"
 	"runtime"
+	"sync"
 	"sync/atomic"
 	"unsafe"
 )
@@ -38,6 +39,29 @@ func init() {
 	if unsafe.Sizeof(reflectedValue{}) != unsafe.Sizeof(reflect.Value{}) {
 		panic("ABI not match")
 	}
+	checkHeaderLayouts()
+}
+
+// checkHeaderLayouts verifies the header structs behaviorally: matching
+// sizes are necessary but not sufficient, a future Go version could reorder
+// fields and corrupt memory silently. reading known values back through the
+// headers fails fast instead.
+func checkHeaderLayouts() {
+	s := "lac"
+	if h := (*stringHeader)(unsafe.Pointer(&s)); h.Len != 3 || *(*byte)(h.Data) != 'l' {
+		panic(fmt.Errorf("lac: stringHeader layout does not match %v", runtime.Version()))
+	}
+
+	sl := make([]int32, 2, 5)
+	sl[0] = 7
+	if h := (*sliceHeader)(unsafe.Pointer(&sl)); h.Len != 2 || h.Cap != 5 || *(*int32)(h.Data) != 7 {
+		panic(fmt.Errorf("lac: sliceHeader layout does not match %v", runtime.Version()))
+	}
+
+	var i interface{} = &sl
+	if (*emptyInterface)(unsafe.Pointer(&i)).Data != unsafe.Pointer(&sl) {
+		panic(fmt.Errorf("lac: interface layout does not match %v", runtime.Version()))
+	}
 }
 
 type sliceHeader struct {
@@ -148,12 +172,77 @@ func mayContainsPtr(k reflect.Kind) bool {
 	return true
 }
 
-func noMalloc(f func()) {
+// rtypeHeader mirrors the leading fields of the runtime type descriptor.
+// ptrdata is the number of bytes in the value that can contain pointers.
+type rtypeHeader struct {
+	size    uintptr
+	ptrdata uintptr
+}
+
+var containsPtrCache = struct {
+	spinLock
+	m map[reflect.Type]bool
+}{m: map[reflect.Type]bool{}}
+
+// ContainsPointers reports whether values of type t hold any pointer
+// (incl. slices, strings, maps, chans, funcs and interfaces).
+// results are cached per type.
+func ContainsPointers(t reflect.Type) bool {
+	containsPtrCache.Lock()
+	r, ok := containsPtrCache.m[t]
+	containsPtrCache.Unlock()
+	if ok {
+		return r
+	}
+
+	if h := (*rtypeHeader)((*emptyInterface)(unsafe.Pointer(&t)).Data); h != nil {
+		r = h.ptrdata != 0
+	} else {
+		r = containsPointersSlow(t)
+	}
+
+	containsPtrCache.Lock()
+	containsPtrCache.m[t] = r
+	containsPtrCache.Unlock()
+	return r
+}
+
+// containsPointersSlow is the reflect-only fallback of ContainsPointers.
+func containsPointersSlow(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if containsPointersSlow(t.Field(i).Type) {
+				return true
+			}
+		}
+		return false
+	case reflect.Array:
+		return t.Len() > 0 && containsPointersSlow(t.Elem())
+	default:
+		return mayContainsPtr(t.Kind())
+	}
+}
+
+// MallocCount returns how many heap mallocs f performed. the counting
+// primitive behind noMalloc, exported so user tests can assert exact counts
+// ("exactly 2 mallocs for the map creation") instead of the all-or-nothing
+// panic. ReadMemStats stops the world, so keep it out of hot paths; counts
+// are process-wide, run such assertions without parallel tests.
+func MallocCount(f func()) uint64 {
 	var s, e runtime.MemStats
 	runtime.ReadMemStats(&s)
 	f()
 	runtime.ReadMemStats(&e)
-	if n := e.Mallocs - s.Mallocs; n > 0 {
+	return e.Mallocs - s.Mallocs
+}
+
+func noMalloc(f func()) {
+	var s runtime.MemStats
+	runtime.ReadMemStats(&s)
+	if n := MallocCount(f); n > 0 {
+		var e runtime.MemStats
+		runtime.ReadMemStats(&e)
 		panic(fmt.Errorf("has %v malloc, bytes: %v", n, e.Alloc-s.Alloc))
 	}
 }
@@ -223,3 +312,47 @@ func anyEq(a, b any) bool {
 func eq[T comparable](a, b T) bool {
 	return a == b
 }
+
+// the sync primitives whose state must never be carried by reused memory
+// or copied after first use.
+var syncPrimitiveTypes = map[reflect.Type]struct{}{
+	reflect.TypeOf(sync.Mutex{}):     {},
+	reflect.TypeOf(sync.RWMutex{}):   {},
+	reflect.TypeOf(sync.Once{}):      {},
+	reflect.TypeOf(sync.WaitGroup{}): {},
+	reflect.TypeOf(sync.Cond{}):      {},
+	reflect.TypeOf(sync.Pool{}):      {},
+	reflect.TypeOf(sync.Map{}):       {},
+}
+
+var containsSyncCache = struct {
+	spinLock
+	m map[reflect.Type]bool
+}{m: map[reflect.Type]bool{}}
+
+// containsSyncPrimitives reports whether t embeds any sync primitive,
+// directly or through nested structs and arrays. results are cached per type.
+func containsSyncPrimitives(t reflect.Type) bool {
+	containsSyncCache.Lock()
+	r, ok := containsSyncCache.m[t]
+	containsSyncCache.Unlock()
+	if ok {
+		return r
+	}
+
+	if _, r = syncPrimitiveTypes[t]; !r {
+		switch t.Kind() {
+		case reflect.Struct:
+			for i := 0; i < t.NumField() && !r; i++ {
+				r = containsSyncPrimitives(t.Field(i).Type)
+			}
+		case reflect.Array:
+			r = t.Len() > 0 && containsSyncPrimitives(t.Elem())
+		}
+	}
+
+	containsSyncCache.Lock()
+	containsSyncCache.m[t] = r
+	containsSyncCache.Unlock()
+	return r
+}
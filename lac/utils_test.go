@@ -0,0 +1,127 @@
+/*
+ * Linear Allocator
+ *
+ * Improve the memory allocation and garbage collection performance.
+ *
+ * Copyright (C) 2020-2023 crazybie@github.com.
+ * https://github.com/crazybie/linear_ac
+ */
+
+package lac
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ContainsPointers(t *testing.T) {
+	type flat struct {
+		a int
+		b [4]float64
+		c bool
+	}
+	type withPtr struct {
+		a int
+		p *int
+	}
+	type nested struct {
+		f flat
+		w withPtr
+	}
+	type nestedFlat struct {
+		f flat
+		a [2]flat
+	}
+
+	cases := []struct {
+		tp  reflect.Type
+		has bool
+	}{
+		{reflect.TypeOf(int(0)), false},
+		{reflect.TypeOf(float64(0)), false},
+		{reflect.TypeOf(""), true},
+		{reflect.TypeOf((*int)(nil)), true},
+		{reflect.TypeOf([]int{}), true},
+		{reflect.TypeOf(map[int]int{}), true},
+		{reflect.TypeOf(flat{}), false},
+		{reflect.TypeOf(withPtr{}), true},
+		{reflect.TypeOf(nested{}), true},
+		{reflect.TypeOf(nestedFlat{}), false},
+		{reflect.TypeOf([3]*int{}), true},
+		{reflect.TypeOf([3]flat{}), false},
+		{reflect.TypeOf([0]*int{}), false},
+	}
+
+	for _, c := range cases {
+		// twice to cover the cached path.
+		for i := 0; i < 2; i++ {
+			if got := ContainsPointers(c.tp); got != c.has {
+				t.Errorf("%v: got %v", c.tp, got)
+			}
+		}
+		if got := containsPointersSlow(c.tp); got != c.has {
+			t.Errorf("slow %v: got %v", c.tp, got)
+		}
+	}
+}
+
+func Test_CheckHeaderLayouts(t *testing.T) {
+	// ran once at init already; must stay callable and silent.
+	checkHeaderLayouts()
+}
+
+func Test_AllocSize(t *testing.T) {
+	ac := acPool.Get()
+	defer ac.Release()
+
+	type padded struct {
+		a byte
+		b int64
+	}
+	type odd struct {
+		a [3]byte
+	}
+
+	if AllocSize(reflect.TypeOf(padded{})) != AllocSizeOf[padded]() {
+		t.Errorf("variants disagree")
+	}
+
+	check := func(allocSz int, alloc func()) {
+		before := ac.Used()
+		alloc()
+		if got := ac.Used() - before; got != int64(allocSz) {
+			t.Errorf("advance %v, AllocSize %v", got, allocSz)
+		}
+	}
+	check(AllocSizeOf[byte](), func() { _ = New[byte](ac) })
+	check(AllocSizeOf[odd](), func() { _ = New[odd](ac) })
+	check(AllocSizeOf[padded](), func() { _ = New[padded](ac) })
+	check(AllocSizeOf[PbItem](), func() { _ = New[PbItem](ac) })
+}
+
+func Test_MallocCount(t *testing.T) {
+	// debug tracking allocates natively and would show up in the counts.
+	acPool.EnableDebugMode(false)
+	ac := acPool.Get()
+	defer ac.Release()
+
+	// warm the chunk so the measured section hits the fast path only.
+	New[PbItem](ac)
+
+	if n := MallocCount(func() {
+		for i := 0; i < 10; i++ {
+			item := New[PbItem](ac)
+			item.Id = ac.Int(i)
+		}
+	}); n != 0 {
+		t.Errorf("expect 0 mallocs, got %v", n)
+	}
+
+	var sink interface{}
+	if n := MallocCount(func() {
+		sink = make([]byte, 10240)
+	}); n == 0 {
+		t.Errorf("expect mallocs to be counted")
+	}
+	_ = sink
+}